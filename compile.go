@@ -0,0 +1,594 @@
+package vingo
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// -------------------- compile (tokens -> AST nodes) --------------------
+
+// parser: tek bir template'in token listesini node ağacına çevirirken tutulan durum
+type parser struct {
+	eng        *Engine
+	name       string // template'in tam yolu; include/extends isimleri buna göre çözülür
+	src        string // hata mesajlarındaki satır alıntıları için kaynak
+	tokens     []*Token
+	nodes      []Node       // üst seviye node'lar
+	extends    string       // <{ extends "..." }> ile verilen üst template, yoksa boş
+	extendsTok *Token       // extends etiketinin kendisi (hata konumu için)
+	blocks     []*BlockNode // tanım sırasına göre template'teki bütün bloklar
+	open       []*BlockNode // şu an içinde bulunulan bloklar (super için)
+	loops      []*ForNode   // şu an içinde bulunulan for döngüleri (break/continue ve loop.Last için)
+}
+
+func newParser(e *Engine, name, src string) *parser {
+	return &parser{eng: e, name: name, src: src, tokens: tokenize(name, src, e.trimBlocks)}
+}
+
+// errorf: verilen konum için satır alıntısı içeren bir *ParseError üretir
+func (p *parser) errorf(pos Pos, format string, args ...interface{}) error {
+	return &ParseError{Pos: pos, Msg: fmt.Sprintf(format, args...), Excerpt: sourceLine(p.src, pos.Line)}
+}
+
+// exprErr: pos'taki etiketin src ifadesindeki bir hatayı raporlar. Hata bir syntaxError ise konum,
+// etiketin içinde hatalı token'ın bulunduğu sütundur; aksi halde etiketin kendisi.
+func (p *parser) exprErr(pos Pos, src string, err error) error {
+	var se *syntaxError
+	if errors.As(err, &se) {
+		pos = p.exprPos(pos, src, se.off)
+	}
+	return p.errorf(pos, "%v", err)
+}
+
+// exprPos: pos'ta başlayan etiketin içindeki src ifadesinin off. byte'ının konumu; src etiketin
+// içinde bulunamazsa pos döner
+func (p *parser) exprPos(pos Pos, src string, off int) Pos {
+	start := 0
+	for l := 1; l < pos.Line; l++ {
+		start += strings.IndexByte(p.src[start:], '\n') + 1
+	}
+	start += pos.Col - 1
+	if start < 0 || start+2 > len(p.src) {
+		return pos
+	}
+	end := tagEnd(p.src[start+2:])
+	if end < 0 {
+		end = len(p.src) - start - 2
+	}
+	i := strings.Index(p.src[start+2:start+2+end], src)
+	if i < 0 || i+off > end {
+		return pos
+	}
+	for _, c := range []byte(p.src[start : start+2+i+off]) {
+		if c == '\n' {
+			pos.Line, pos.Col = pos.Line+1, 1
+		} else {
+			pos.Col++
+		}
+	}
+	return pos
+}
+
+// includeArgsPattern: include etiketinin argümanları: isim (tırnaklı veya değişken), opsiyonel
+// "with <değişken>" ve opsiyonel "only"
+var includeArgsPattern = regexp.MustCompile(`^("(?:[^"\\]|\\.)*"|'[^']*'|\w+(?:\.\w+)*)(?:\s+with\s+(\w+(?:\.\w+)*))?(\s+only)?$`)
+
+// parse: bütün token'ları üst seviye node listesine çevirir
+func (p *parser) parse() error {
+	nodes := []Node{}
+	i := 0
+	for i < len(p.tokens) {
+		t := p.tokens[i]
+		if t.Type == TExtends {
+			if p.extends != "" {
+				return p.errorf(t.Pos, "template extends more than one template")
+			}
+			name, err := strconv.Unquote(t.Value)
+			if err != nil {
+				return p.errorf(t.Pos, "extends expects a quoted template name, got %s", t.Value)
+			}
+			p.extends = name
+			p.extendsTok = t
+			i++
+			continue
+		}
+		n, ni, err := p.parseNode(i)
+		if err != nil {
+			return err
+		}
+		nodes = append(nodes, n)
+		i = ni
+	}
+	p.nodes = nodes
+	return nil
+}
+
+// parseNode: tokens[i] ile başlayan tek bir node'u (gerekiyorsa bütün gövdesiyle) derler.
+// Kapanış veya ara etiketler (/if, else, case...) burada beklenmedik token sayılır;
+// onları ilgili parseX fonksiyonu kendisi tüketir.
+func (p *parser) parseNode(i int) (Node, int, error) {
+	t := p.tokens[i]
+	switch t.Type {
+	case TText:
+		return &TextNode{Pos: t.Pos, Text: t.Value}, i + 1, nil
+	case TVar:
+		n, err := p.parseVar(t)
+		if err != nil {
+			return nil, 0, err
+		}
+		return n, i + 1, nil
+	case TIf:
+		return p.parseIf(i)
+	case TFor:
+		return p.parseFor(i)
+	case TSwitch:
+		return p.parseSwitch(i)
+	case TBlock:
+		return p.parseBlock(i)
+	case TSuper:
+		if len(p.open) == 0 {
+			return nil, 0, p.errorf(t.Pos, "super used outside of a block")
+		}
+		sn := &SuperNode{Pos: t.Pos}
+		b := p.open[len(p.open)-1]
+		b.supers = append(b.supers, sn)
+		return sn, i + 1, nil
+	case TInclude:
+		n, err := p.parseInclude(t)
+		if err != nil {
+			return nil, 0, err
+		}
+		return n, i + 1, nil
+	case TSet:
+		n, err := p.parseSet(t)
+		if err != nil {
+			return nil, 0, err
+		}
+		return n, i + 1, nil
+	case TWith:
+		return p.parseWith(i)
+	case TCapture:
+		return p.parseCapture(i)
+	case TBreak, TContinue:
+		n, err := p.parseLoopControl(t)
+		if err != nil {
+			return nil, 0, err
+		}
+		return n, i + 1, nil
+	case TRaw:
+		return p.parseRaw(i)
+	case TComment:
+		return nil, 0, p.errorf(t.Pos, "unclosed comment: missing #}>")
+	case TExtends:
+		return nil, 0, p.errorf(t.Pos, "extends must be a top-level tag")
+	}
+	return nil, 0, p.errorf(t.Pos, "unexpected <{ %s }>", t.Raw)
+}
+
+func (p *parser) parseIf(start int) (*IfNode, int, error) {
+	// tokens[start] is TIf
+	root := &IfNode{Pos: p.tokens[start].Pos}
+	cond, err := p.parseExpr(p.tokens[start])
+	if err != nil {
+		return nil, 0, err
+	}
+	branches := []IfBranch{{Pos: p.tokens[start].Pos, Expr: p.tokens[start].Value, Body: []Node{}, cond: cond}}
+	elseBody := []Node{}
+	currentBody := &branches[0].Body
+
+	i := start + 1
+	for i < len(p.tokens) {
+		t := p.tokens[i]
+		switch t.Type {
+		case TEndIf:
+			// finish
+			root.Branches = branches
+			root.Else = elseBody
+			return root, i + 1, nil
+		case TElseIf:
+			cond, err := p.parseExpr(t)
+			if err != nil {
+				return nil, 0, err
+			}
+			branches = append(branches, IfBranch{Pos: t.Pos, Expr: t.Value, Body: []Node{}, cond: cond})
+			currentBody = &branches[len(branches)-1].Body
+			i++
+			continue
+		case TElse:
+			elseBody = []Node{}
+			currentBody = &elseBody
+			i++
+			continue
+		}
+		n, ni, err := p.parseNode(i)
+		if err != nil {
+			return nil, 0, err
+		}
+		*currentBody = append(*currentBody, n)
+		i = ni
+	}
+	return nil, 0, p.errorf(p.tokens[start].Pos, "unclosed if: missing <{ /if }>")
+}
+
+func (p *parser) parseFor(start int) (*ForNode, int, error) {
+	// tokens[start] is TFor with Value like "idx, item:listExpr" or "item:listExpr"
+	parts := strings.SplitN(p.tokens[start].Value, ":", 2)
+	if len(parts) != 2 {
+		return nil, 0, p.errorf(p.tokens[start].Pos, "invalid for tag: %s", p.tokens[start].Raw)
+	}
+	left := strings.TrimSpace(parts[0])
+	listExpr := strings.TrimSpace(parts[1])
+	list, err := parseExpr(listExpr)
+	if err != nil {
+		return nil, 0, p.exprErr(p.tokens[start].Pos, listExpr, err)
+	}
+	if err := p.checkFuncs(p.tokens[start].Pos, list); err != nil {
+		return nil, 0, err
+	}
+
+	indexVar := ""
+	itemVar := ""
+	if strings.Contains(left, ",") {
+		lp := strings.SplitN(left, ",", 2)
+		indexVar = strings.TrimSpace(lp[0])
+		itemVar = strings.TrimSpace(lp[1])
+	} else {
+		itemVar = left
+	}
+
+	node := &ForNode{Pos: p.tokens[start].Pos, IndexVar: indexVar, ItemVar: itemVar, ListExpr: listExpr, Body: []Node{}, list: list}
+	currentBody := &node.Body
+	p.loops = append(p.loops, node)
+	i := start + 1
+	for i < len(p.tokens) {
+		switch p.tokens[i].Type {
+		case TEndFor:
+			if currentBody == &node.Body {
+				p.loops = p.loops[:len(p.loops)-1]
+			}
+			return node, i + 1, nil
+		case TElse:
+			if currentBody == &node.Else {
+				return nil, 0, p.errorf(p.tokens[i].Pos, "for has more than one <{ else }>")
+			}
+			// else gövdesi döngünün dışında sayılır; orada break/continue kullanılamaz
+			p.loops = p.loops[:len(p.loops)-1]
+			node.Else = []Node{}
+			currentBody = &node.Else
+			i++
+			continue
+		}
+		n, ni, err := p.parseNode(i)
+		if err != nil {
+			return nil, 0, err
+		}
+		*currentBody = append(*currentBody, n)
+		i = ni
+	}
+	return nil, 0, p.errorf(p.tokens[start].Pos, "unclosed for: missing <{ /for }>")
+}
+
+// parseLoopControl: <{ break }>, <{ continue }> veya koşullu halleri <{ break if expr }>
+func (p *parser) parseLoopControl(t *Token) (*LoopControlNode, error) {
+	if len(p.loops) == 0 {
+		return nil, p.errorf(t.Pos, "%s used outside of a for loop", strings.Fields(t.Raw)[0])
+	}
+	node := &LoopControlNode{Pos: t.Pos, Break: t.Type == TBreak, Cond: t.Value}
+	if t.Value != "" {
+		cond, err := p.parseExpr(t)
+		if err != nil {
+			return nil, err
+		}
+		node.cond = cond
+	}
+	return node, nil
+}
+
+func (p *parser) parseSwitch(start int) (*SwitchNode, int, error) {
+	value, err := p.parseExpr(p.tokens[start])
+	if err != nil {
+		return nil, 0, err
+	}
+	node := &SwitchNode{Pos: p.tokens[start].Pos, Expr: p.tokens[start].Value, Cases: []SwitchCase{}, Default: []Node{}, value: value}
+	i := start + 1
+	currentCond := ""
+	currentPos := Pos{}
+	var currentConds []expr
+	currentBody := []Node{}
+	inCase := false // ilk case/default görüldü mü
+
+	flushCase := func() {
+		if currentCond != "" {
+			node.Cases = append(node.Cases, SwitchCase{Pos: currentPos, Cond: currentCond, Body: currentBody, conds: currentConds})
+		} else if len(currentBody) > 0 {
+			// currentCond boşsa ve body varsa, bu default case'dir
+			node.Default = currentBody
+		}
+		currentCond = ""
+		currentBody = []Node{}
+	}
+
+	for i < len(p.tokens) {
+		t := p.tokens[i]
+		switch t.Type {
+		case TEndSwitch:
+			// switch bitiyor
+			flushCase()
+			return node, i + 1, nil
+		case TCase:
+			// yeni case
+			flushCase()
+			conds, err := parseExprList(t.Value)
+			if err != nil {
+				return nil, 0, p.exprErr(t.Pos, t.Value, err)
+			}
+			if err := p.checkFuncs(t.Pos, conds...); err != nil {
+				return nil, 0, err
+			}
+			currentCond = t.Value
+			currentPos = t.Pos
+			currentConds = conds
+			inCase = true
+			i++
+			continue
+		case TDefault:
+			// default case
+			flushCase()
+			currentCond = "" // Default case için cond boş
+			inCase = true
+			i++
+			continue
+		}
+		if !inCase {
+			// switch ile ilk case arasındaki girinti atlanır; başka bir içerik olamaz
+			if t.Type == TText && strings.TrimSpace(t.Value) == "" {
+				i++
+				continue
+			}
+			return nil, 0, p.errorf(t.Pos, "unexpected content before the first case of switch")
+		}
+		n, ni, err := p.parseNode(i)
+		if err != nil {
+			return nil, 0, err
+		}
+		currentBody = append(currentBody, n)
+		i = ni
+	}
+	return nil, 0, p.errorf(p.tokens[start].Pos, "unclosed switch: missing <{ /switch }>")
+}
+
+func (p *parser) parseBlock(start int) (*BlockNode, int, error) {
+	node := &BlockNode{Pos: p.tokens[start].Pos, Name: p.tokens[start].Value, Body: []Node{}}
+	for _, b := range p.blocks {
+		if b.Name == node.Name {
+			return nil, 0, p.errorf(node.Pos, "block %q already defined at %d:%d", node.Name, b.Line, b.Col)
+		}
+	}
+	p.blocks = append(p.blocks, node)
+	p.open = append(p.open, node)
+	// bloğu ezen alt template'in loop.Last'ı okuyup okumadığı burada bilinmez
+	p.markLast()
+	defer func() { p.open = p.open[:len(p.open)-1] }()
+
+	i := start + 1
+	for i < len(p.tokens) {
+		if p.tokens[i].Type == TEndBlock {
+			return node, i + 1, nil
+		}
+		n, ni, err := p.parseNode(i)
+		if err != nil {
+			return nil, 0, err
+		}
+		node.Body = append(node.Body, n)
+		i = ni
+	}
+	return nil, 0, p.errorf(node.Pos, "unclosed block %q: missing <{ /block }>", node.Name)
+}
+
+// parseSet: <{ set name = ifade | filtre ... }>
+func (p *parser) parseSet(t *Token) (*SetNode, error) {
+	name, src, _ := strings.Cut(t.Value, ":")
+	x, filters, err := parsePipeline(src)
+	if err != nil {
+		return nil, p.exprErr(t.Pos, src, err)
+	}
+	if err := p.checkPipeline(t.Pos, x, filters); err != nil {
+		return nil, err
+	}
+	return &SetNode{Pos: t.Pos, Name: name, Expr: src, Filters: filters, value: x}, nil
+}
+
+// parseWith: <{ with ifade as name }> ... <{ /with }>
+func (p *parser) parseWith(start int) (*WithNode, int, error) {
+	t := p.tokens[start]
+	name, src, _ := strings.Cut(t.Value, ":")
+	x, err := parseExpr(src)
+	if err != nil {
+		return nil, 0, p.exprErr(t.Pos, src, err)
+	}
+	if err := p.checkFuncs(t.Pos, x); err != nil {
+		return nil, 0, err
+	}
+	node := &WithNode{Pos: t.Pos, Name: name, Expr: src, Body: []Node{}, value: x}
+	i := start + 1
+	for i < len(p.tokens) {
+		if p.tokens[i].Type == TEndWith {
+			return node, i + 1, nil
+		}
+		n, ni, err := p.parseNode(i)
+		if err != nil {
+			return nil, 0, err
+		}
+		node.Body = append(node.Body, n)
+		i = ni
+	}
+	return nil, 0, p.errorf(t.Pos, "unclosed with: missing <{ /with }>")
+}
+
+// parseCapture: <{ capture name }> ... <{ /capture }>
+func (p *parser) parseCapture(start int) (*CaptureNode, int, error) {
+	node := &CaptureNode{Pos: p.tokens[start].Pos, Name: p.tokens[start].Value, Body: []Node{}}
+	i := start + 1
+	for i < len(p.tokens) {
+		if p.tokens[i].Type == TEndCapture {
+			return node, i + 1, nil
+		}
+		n, ni, err := p.parseNode(i)
+		if err != nil {
+			return nil, 0, err
+		}
+		node.Body = append(node.Body, n)
+		i = ni
+	}
+	return nil, 0, p.errorf(node.Pos, "unclosed capture %q: missing <{ /capture }>", node.Name)
+}
+
+// parseRaw: <{ raw }> ... <{ /raw }>. Aradaki metni tokenizer olduğu gibi tek bir text token'ı
+// olarak verir; burada sadece kapanış kontrol edilir.
+func (p *parser) parseRaw(start int) (Node, int, error) {
+	t := p.tokens[start]
+	i := start + 1
+	node := &TextNode{Pos: t.Pos}
+	if i < len(p.tokens) && p.tokens[i].Type == TText {
+		node.Pos, node.Text = p.tokens[i].Pos, p.tokens[i].Value
+		i++
+	}
+	if i >= len(p.tokens) || p.tokens[i].Type != TEndRaw {
+		return nil, 0, p.errorf(t.Pos, "unclosed raw: missing <{ /raw }>")
+	}
+	return node, i + 1, nil
+}
+
+func (p *parser) parseInclude(t *Token) (*IncludeNode, error) {
+	m := includeArgsPattern.FindStringSubmatch(t.Value)
+	if m == nil {
+		return nil, p.errorf(t.Pos, "invalid include tag: %s", t.Raw)
+	}
+	node := &IncludeNode{Pos: t.Pos, With: m[2], Only: m[3] != "", from: p.name}
+	if !node.Only {
+		// include edilen template loop'u görür; loop.Last'ı okuyup okumadığı burada bilinmez
+		p.markLast()
+	}
+	if strings.HasPrefix(m[1], "\"") || strings.HasPrefix(m[1], "'") {
+		node.Name = literalFromString(m[1]).(string)
+	} else {
+		node.NameExpr = m[1]
+		x, err := parseExpr(m[1])
+		if err != nil {
+			return nil, p.exprErr(t.Pos, m[1], err)
+		}
+		node.nameExpr = x
+	}
+	if node.With != "" {
+		x, err := parseExpr(node.With)
+		if err != nil {
+			return nil, p.exprErr(t.Pos, node.With, err)
+		}
+		node.with = x
+	}
+	return node, nil
+}
+
+// parseExpr: etiketin ifadesini (t.Value) derler
+func (p *parser) parseExpr(t *Token) (expr, error) {
+	x, err := parseExpr(t.Value)
+	if err != nil {
+		return nil, p.exprErr(t.Pos, t.Value, err)
+	}
+	if err := p.checkFuncs(t.Pos, x); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// checkFuncs: ifadelerde çağrılan fonksiyonların engine'de tanımlı olduğunu kontrol eder;
+// filtreler gibi fonksiyonlar da template derlenmeden önce eklenmiş olmalıdır.
+func (p *parser) checkFuncs(pos Pos, xs ...expr) error {
+	p.markLast(xs...)
+	var err error
+	for _, x := range xs {
+		walkExpr(x, func(e expr) {
+			if c, ok := e.(*callExpr); ok && err == nil {
+				if _, ok := p.eng.function(c.name); !ok {
+					err = p.errorf(pos, "unknown function %q", c.name)
+				}
+			}
+		})
+	}
+	return err
+}
+
+// markLast: ifadelerde loop.Last (veya loop.Parent.Last) okunuyorsa ilgili döngüyü işaretler; uzunluğu
+// bilinmeyen kaynaklarda Last sadece işaretli döngülerde bir eleman ileriden okunarak hesaplanır.
+// loop değişkeni olduğu gibi başka bir yere verilirse (fonksiyon argümanı, set, with ...) veya xs
+// boşsa içinde bulunulan bütün döngüler işaretlenir.
+func (p *parser) markLast(xs ...expr) {
+	all := len(xs) == 0
+	for _, x := range xs {
+		used := map[expr]bool{} // alan veya metot erişimiyle okunan loop identExpr'leri
+		walkExpr(x, func(e expr) {
+			var base expr
+			switch e := e.(type) {
+			case *fieldExpr:
+				up, last := 0, e.name == "Last"
+				for base = e.x; ; up++ {
+					f, ok := base.(*fieldExpr)
+					if !ok || f.name != "Parent" {
+						break
+					}
+					base = f.x
+				}
+				if id, ok := base.(*identExpr); ok && id.name == "loop" {
+					used[id] = true
+					if last && up < len(p.loops) {
+						p.loops[len(p.loops)-1-up].last = true
+					}
+				}
+			case *methodExpr:
+				if id, ok := e.x.(*identExpr); ok && id.name == "loop" {
+					used[id] = true
+				}
+			case *identExpr:
+				if e.name == "loop" && !used[e] {
+					all = true
+				}
+			}
+		})
+	}
+	if all {
+		for _, l := range p.loops {
+			l.last = true
+		}
+	}
+}
+
+// parseVar: "ifade | filtre | filtre(arg, ...)" biçimindeki çıktı etiketini derler
+func (p *parser) parseVar(t *Token) (*VarNode, error) {
+	x, filters, err := parsePipeline(t.Value)
+	if err != nil {
+		return nil, p.exprErr(t.Pos, t.Value, err)
+	}
+	if err := p.checkPipeline(t.Pos, x, filters); err != nil {
+		return nil, err
+	}
+	return &VarNode{Pos: t.Pos, Name: t.Value, Filters: filters, value: x}, nil
+}
+
+// checkPipeline: bir filtre zincirindeki filtrelerin ve çağrılan fonksiyonların tanımlı olduğunu kontrol eder
+func (p *parser) checkPipeline(pos Pos, x expr, filters []Filter) error {
+	if err := p.checkFuncs(pos, x); err != nil {
+		return err
+	}
+	for _, f := range filters {
+		if _, ok := p.eng.filter(f.Name); !ok {
+			return p.errorf(pos, "unknown filter %q", f.Name)
+		}
+		if err := p.checkFuncs(pos, f.args...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
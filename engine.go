@@ -0,0 +1,423 @@
+package vingo
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Options: New ile oluşturulan Engine'in ayarları.
+// Sıfır değer (Options{}) güvenli varsayılanları verir: otomatik HTML kaçışı açık, kök dizin yok.
+type Options struct {
+	// Root: göreli template isimleri bu dizine göre çözülür. Boşsa çalışma dizini kullanılır.
+	// FS verilmişse Root, FS içindeki bir alt dizindir.
+	Root string
+	// FS: template'ler işletim sistemi yerine bu dosya sisteminden okunur (örneğin //go:embed
+	// ile gömülen bir embed.FS). İsimler fs.FS kurallarına uyan, '/' ile ayrılmış yollardır.
+	FS fs.FS
+	// NoAutoEscape: değişken çıktılarında HTML kaçışını kapatır (önerilmez).
+	NoAutoEscape bool
+	// StrictVariables: çıktı, koşul, döngü ve switch ifadelerinde bulunamayan her değişkeni
+	// render hatasına çevirir. <{ name | "varsayılan" }> şeklinde default verilen çıktılar hariçtir.
+	StrictVariables bool
+	// JSONTags: struct alanları vingo etiketi yoksa json etiketindeki isimle de bulunur, böylece
+	// API'de JSON olarak dönen struct'lar template'lerde aynı isimlerle kullanılabilir.
+	JSONTags bool
+	// CaseInsensitiveFields: struct alanlarını büyük/küçük harf farkı gözetmeden bulur
+	// (<{ user.name }> -> Name). Tam eşleşen isim her zaman önce gelir.
+	CaseInsensitiveFields bool
+	// TrimBlocks: çıktı etiketi olmayan etiketlerden (<{ if }>, <{ /for }> ...) hemen sonra gelen
+	// tek satır sonunu siler, böylece kendi satırında duran etiketler çıktıda boş satır bırakmaz.
+	TrimBlocks bool
+	// Globals: bu engine ile render edilen her template'e görünen değerler.
+	// Render'a verilen data aynı isimde bir anahtar içerirse data kazanır.
+	Globals map[string]interface{}
+}
+
+// Engine: kendi cache'ine, kaçış ayarına, kök dizinine, filtrelerine ve global değerlerine sahip
+// bağımsız bir template motoru. Farklı Engine'ler birbirinin durumunu görmez; bir Engine
+// birden fazla goroutine'den aynı anda kullanılabilir.
+type Engine struct {
+	root            string
+	fsys            fs.FS
+	autoEscape      bool
+	strict          bool
+	jsonTags        bool
+	caseInsensitive bool
+	trimBlocks      bool
+	globals         map[string]interface{}
+
+	fields sync.Map // reflect.Type -> *structFields
+
+	mu       sync.RWMutex
+	filters  map[string]FilterFunc
+	funcs    map[string]reflect.Value
+	escapers map[string]Escaper
+	cache    map[string]*Template // resolve edilmiş isim + "\x00" + kaçış anahtarı -> template
+}
+
+// defaultEngine: paket seviyesindeki Render fonksiyonunun kullandığı engine
+var defaultEngine = New(Options{})
+
+// New: verilen ayarlarla yeni bir Engine oluşturur.
+func New(opts Options) *Engine {
+	e := &Engine{
+		root:            opts.Root,
+		fsys:            opts.FS,
+		autoEscape:      !opts.NoAutoEscape,
+		strict:          opts.StrictVariables,
+		jsonTags:        opts.JSONTags,
+		caseInsensitive: opts.CaseInsensitiveFields,
+		trimBlocks:      opts.TrimBlocks,
+		globals:         make(map[string]interface{}, len(opts.Globals)),
+		filters:         make(map[string]FilterFunc, len(builtinFilters)),
+		funcs:           map[string]reflect.Value{},
+		escapers:        make(map[string]Escaper, len(builtinEscapers)),
+		cache:           map[string]*Template{},
+	}
+	for k, v := range opts.Globals {
+		e.globals[k] = v
+	}
+	for k, f := range builtinFilters {
+		e.filters[k] = f
+	}
+	for k, esc := range builtinEscapers {
+		e.escapers[k] = esc
+	}
+	return e.Funcs(builtinFuncs)
+}
+
+// Render: template dosyasını oku, compile et (gerekirse cache'den), ve işle
+func (e *Engine) Render(file string, data map[string]interface{}) (string, error) {
+	out := &strings.Builder{}
+	if err := e.RenderTo(out, file, data); err != nil {
+		return "", err
+	}
+	return out.String(), nil
+}
+
+// RenderTo: Render gibi, ancak çıktıyı doğrudan w'ya akıtır.
+// Hata durumunda w'ya o ana kadar yazılmış kısmi çıktı kalabilir.
+func (e *Engine) RenderTo(w io.Writer, file string, data map[string]interface{}) error {
+	name := e.resolve(file)
+	tpl, err := e.getOrCompile(name, e.escapingFor(name))
+	if err != nil {
+		return err
+	}
+	return tpl.Execute(w, data)
+}
+
+// Compile: src içeriğini bu engine ile derler. Sonuç cache'e konmaz; tekrar tekrar
+// kullanmak için dönen Template saklanmalıdır.
+func (e *Engine) Compile(name, src string) (*Template, error) {
+	return e.CompileWith(name, src, nil)
+}
+
+// CompileWith: Compile gibi, ancak çıktılar ismin uzantısından bağımsız olarak esc ile kaçışlanır.
+// esc nil ise Compile'daki gibi uzantıya göre seçilir.
+func (e *Engine) CompileWith(name, src string, esc Escaper) (*Template, error) {
+	file := e.resolve(name)
+	mode := e.escapingFor(file)
+	if esc != nil && e.autoEscape {
+		// bu kaçışla derlenen include'lar engine'in cache'ine değil template'in kendi cache'ine
+		// konur ve template ile birlikte bırakılır
+		mode = escaping{key: "custom", esc: esc, cache: &templateCache{m: map[string]*Template{}}}
+	}
+	nodes, deps, err := e.compile(file, src, mode)
+	if err != nil {
+		return nil, err
+	}
+	return &Template{Name: name, Nodes: nodes, deps: deps, file: file, eng: e}, nil
+}
+
+// resolve: template ismini cache anahtarı olarak da kullanılan tam yola çevirir.
+// FS varsa Root'a göre temizlenmiş bir fs yolu, yoksa mutlak bir dosya yolu döner.
+func (e *Engine) resolve(file string) string {
+	if e.fsys != nil {
+		return path.Join(e.root, strings.TrimPrefix(filepath.ToSlash(file), "/"))
+	}
+	if e.root != "" && !filepath.IsAbs(file) {
+		file = filepath.Join(e.root, file)
+	}
+	abs, err := filepath.Abs(file)
+	if err != nil {
+		return file
+	}
+	return abs
+}
+
+// stat: resolve edilmiş template'in değişiklik zamanı
+func (e *Engine) stat(name string) (time.Time, error) {
+	var (
+		info fs.FileInfo
+		err  error
+	)
+	if e.fsys != nil {
+		info, err = fs.Stat(e.fsys, name)
+	} else {
+		info, err = os.Stat(name)
+	}
+	if err != nil {
+		return time.Time{}, err
+	}
+	return info.ModTime(), nil
+}
+
+// read: resolve edilmiş template'in içeriği
+func (e *Engine) read(name string) ([]byte, error) {
+	if e.fsys != nil {
+		return fs.ReadFile(e.fsys, name)
+	}
+	return os.ReadFile(name)
+}
+
+// getOrCompile: cache kontrolü + compile; name mode kaçışıyla derlenir
+func (e *Engine) getOrCompile(name string, mode escaping) (*Template, error) {
+	mod, err := e.stat(name)
+	if err != nil {
+		return nil, err
+	}
+
+	mu, cache := &e.mu, e.cache
+	if mode.cache != nil {
+		mu, cache = &mode.cache.mu, mode.cache.m
+	}
+	key := name + "\x00" + mode.key
+	mu.RLock()
+	tpl, exists := cache[key]
+	mu.RUnlock()
+
+	if exists && tpl.ModTime.Equal(mod) && e.fresh(tpl) {
+		return tpl, nil
+	}
+
+	// compile
+	b, err := e.read(name)
+	if err != nil {
+		return nil, err
+	}
+	nodes, deps, err := e.compile(name, string(b), mode)
+	if err != nil {
+		return nil, err
+	}
+
+	newTpl := &Template{
+		Name:     name,
+		Filepath: name,
+		Nodes:    nodes,
+		ModTime:  mod,
+		deps:     deps,
+		file:     name,
+		eng:      e,
+	}
+
+	mu.Lock()
+	cache[key] = newTpl
+	mu.Unlock()
+
+	return newTpl, nil
+}
+
+// maxExtendsDepth: sonsuz extends döngülerine karşı üst sınır
+const maxExtendsDepth = 32
+
+// compile: kaynak metni token'lara ayırıp node ağacına çevirir. Template başka bir template'i
+// extend ediyorsa zincirdeki bütün üst template'ler okunur, bloklar en alttaki tanıma bağlanır ve
+// en üstteki template'in ağacı döner. deps, okunan üst template'lerin değişiklik zamanlarıdır.
+// Çıktılar mode ile kaçışlanır.
+func (e *Engine) compile(name, src string, mode escaping) ([]Node, map[string]time.Time, error) {
+	var chain []*parser // chain[0] derlenen template, sonuncusu en üstteki layout
+	var deps map[string]time.Time
+	for {
+		p := newParser(e, name, src)
+		if err := p.parse(); err != nil {
+			return nil, nil, err
+		}
+		chain = append(chain, p)
+		if p.extends == "" {
+			break
+		}
+		if len(chain) > maxExtendsDepth {
+			return nil, nil, p.errorf(p.extendsTok.Pos, "extends chain deeper than %d templates (cycle?)", maxExtendsDepth)
+		}
+
+		name = e.relative(name, p.extends)
+		mod, err := e.stat(name)
+		if err != nil {
+			return nil, nil, p.errorf(p.extendsTok.Pos, "cannot load %q: %v", p.extends, err)
+		}
+		b, err := e.read(name)
+		if err != nil {
+			return nil, nil, p.errorf(p.extendsTok.Pos, "cannot load %q: %v", p.extends, err)
+		}
+		if deps == nil {
+			deps = map[string]time.Time{}
+		}
+		deps[name] = mod
+		src = string(b)
+	}
+
+	// errorf: hatayı konumun bulunduğu template'in kaynağından alıntıyla raporlar
+	errorf := func(pos Pos, format string, args ...interface{}) error {
+		for _, p := range chain {
+			if p.name == pos.File {
+				return p.errorf(pos, format, args...)
+			}
+		}
+		return chain[0].errorf(pos, format, args...)
+	}
+
+	// her blok ismi için tanımlar, en üstteki layout'tan en alttaki template'e doğru
+	defs := map[string][]*BlockNode{}
+	for i := len(chain) - 1; i >= 0; i-- {
+		for _, b := range chain[i].blocks {
+			defs[b.Name] = append(defs[b.Name], b)
+		}
+	}
+	for i := len(chain) - 1; i >= 0; i-- {
+		for _, b := range chain[i].blocks {
+			list := defs[b.Name]
+			b.override = list[len(list)-1]
+			k := 0
+			for list[k] != b {
+				k++
+			}
+			if k == 0 && len(b.supers) > 0 {
+				// en üstteki tanımın ezdiği bir blok yok
+				return nil, nil, errorf(b.supers[0].Pos, "super used in block %q with no parent definition", b.Name)
+			}
+			for _, sn := range b.supers {
+				sn.Body = list[k-1].Body
+			}
+		}
+	}
+	nodes := chain[len(chain)-1].nodes
+	if e.autoEscape {
+		a := &contextAnalyzer{mode: mode, errorf: errorf}
+		if _, err := a.nodes(escContext{}, nodes); err != nil {
+			return nil, nil, err
+		}
+	}
+	return nodes, deps, nil
+}
+
+// relative: from template'inden verilen isme göre başka bir template'in tam yolunu bulur.
+// "/" ile başlayan isimler kök dizine, diğerleri from'un bulunduğu dizine göre çözülür.
+func (e *Engine) relative(from, name string) string {
+	if strings.HasPrefix(name, "/") {
+		return e.resolve(name[1:])
+	}
+	if e.fsys != nil {
+		return path.Join(path.Dir(from), name)
+	}
+	return filepath.Join(filepath.Dir(from), filepath.FromSlash(name))
+}
+
+// within: resolve edilmiş name, Root'un (FS varsa FS'in) içinde mi. Root verilmemiş bir işletim
+// sistemi engine'inde her yol kabul edilir.
+func (e *Engine) within(name string) bool {
+	if e.fsys != nil {
+		root := path.Clean(e.root)
+		return fs.ValidPath(name) && (root == "." || name == root || strings.HasPrefix(name, root+"/"))
+	}
+	if e.root == "" {
+		return true
+	}
+	base, err := filepath.Abs(e.root)
+	if err != nil {
+		return false
+	}
+	rel, err := filepath.Rel(base, name)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// fresh: template'in bağlı olduğu üst template'ler derlendiğinden beri değişmemiş mi
+func (e *Engine) fresh(tpl *Template) bool {
+	for name, mod := range tpl.deps {
+		cur, err := e.stat(name)
+		if err != nil || !cur.Equal(mod) {
+			return false
+		}
+	}
+	return true
+}
+
+// scope: global değerlerin üzerine render data'sını koyarak yeni bir kök veri map'i üretir.
+// Template'teki set ve capture atamaları bu kopyaya yazılır, çağıranın map'i değişmez.
+func (e *Engine) scope(data map[string]interface{}) map[string]interface{} {
+	m := make(map[string]interface{}, len(e.globals)+len(data))
+	for k, v := range e.globals {
+		m[k] = v
+	}
+	for k, v := range data {
+		m[k] = v
+	}
+	return m
+}
+
+// filterNamePattern: template'te yazılabilecek filtre isimleri
+var filterNamePattern = regexp.MustCompile(`^\w+$`)
+
+// RegisterFilter: template'lerde <{ value | name }> veya <{ value | name(arg, ...) }> şeklinde
+// kullanılabilecek bir filtre ekler; aynı isimde bir filtre (yerleşik olanlar dahil) varsa
+// değiştirilir. Geçersiz bir isim veya nil fonksiyon verilirse panic olur.
+func (e *Engine) RegisterFilter(name string, fn FilterFunc) {
+	if !filterNamePattern.MatchString(name) {
+		panic(fmt.Sprintf("vingo: invalid filter name %q", name))
+	}
+	if fn == nil {
+		panic(fmt.Sprintf("vingo: nil function for filter %q", name))
+	}
+	e.mu.Lock()
+	e.filters[name] = fn
+	e.mu.Unlock()
+}
+
+func (e *Engine) filter(name string) (FilterFunc, bool) {
+	e.mu.RLock()
+	f, ok := e.filters[name]
+	e.mu.RUnlock()
+	return f, ok
+}
+
+// funcNamePattern: template'te çağrılabilecek fonksiyon isimleri
+var funcNamePattern = regexp.MustCompile(`^[A-Za-z_]\w*$`)
+
+// Funcs: template'lerde <{ name(arg, ...) }> şeklinde çağrılabilecek fonksiyonlar ekler; aynı
+// isimde bir fonksiyon varsa değiştirilir. Map, text/template ve html/template'in FuncMap
+// türleriyle uyumludur, yani onlar için yazılmış yardımcılar doğrudan verilebilir:
+//
+//	e.Funcs(template.FuncMap{"formatPrice": formatPrice})
+//
+// Her değer tek bir sonuç veya (sonuç, error) dönen bir fonksiyon olmalıdır; aksi halde panic olur.
+// Fonksiyonun döndüğü hata render'ı durdurur. Zincirleme kullanım için e'yi döner.
+func (e *Engine) Funcs(funcs map[string]interface{}) *Engine {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for name, fn := range funcs {
+		if !funcNamePattern.MatchString(name) || isKeyword(name) {
+			panic(fmt.Sprintf("vingo: invalid function name %q", name))
+		}
+		v, err := checkFunc(name, fn)
+		if err != nil {
+			panic("vingo: " + err.Error())
+		}
+		e.funcs[name] = v
+	}
+	return e
+}
+
+func (e *Engine) function(name string) (reflect.Value, bool) {
+	e.mu.RLock()
+	fn, ok := e.funcs[name]
+	e.mu.RUnlock()
+	return fn, ok
+}
@@ -0,0 +1,94 @@
+package vingo
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Pos: bir token veya node'un kaynak içindeki konumu. Line ve Col 1'den başlar;
+// Col, satır başından itibaren byte cinsindendir.
+type Pos struct {
+	File string
+	Line int
+	Col  int
+}
+
+// Position: Pos'u gömen token ve node'ların konumunu döner
+func (p Pos) Position() Pos { return p }
+
+func (p Pos) String() string {
+	if p.Line == 0 {
+		return p.File
+	}
+	return fmt.Sprintf("%s:%d:%d", p.File, p.Line, p.Col)
+}
+
+// ParseError: template derlenirken oluşan hata. errors.As ile yakalanıp konumu ve ilgili
+// kaynak satırı incelenebilir; Error() satırı ve hatanın yerini gösteren bir işaret içerir.
+type ParseError struct {
+	Pos
+	Msg     string
+	Excerpt string // hatanın bulunduğu kaynak satırı, bilinmiyorsa boş
+}
+
+func (e *ParseError) Error() string {
+	msg := fmt.Sprintf("%s: %s", e.Pos, e.Msg)
+	if e.Excerpt == "" || e.Col < 1 {
+		return msg
+	}
+	return msg + "\n\t" + e.Excerpt + "\n\t" + caretPad(e.Excerpt, e.Col-1) + "^"
+}
+
+// caretPad: satırın ilk n byte'ının altına gelecek boşlukları üretir (tab'lar korunur ki
+// işaret doğru sütuna hizalansın)
+func caretPad(line string, n int) string {
+	if n > len(line) {
+		n = len(line)
+	}
+	b := &strings.Builder{}
+	for _, r := range line[:n] {
+		if r == '\t' {
+			b.WriteByte('\t')
+		} else {
+			b.WriteByte(' ')
+		}
+	}
+	return b.String()
+}
+
+// sourceLine: src'nin verilen (1'den başlayan) satırı
+func sourceLine(src string, line int) string {
+	for i := 1; i < line; i++ {
+		nl := strings.IndexByte(src, '\n')
+		if nl < 0 {
+			return ""
+		}
+		src = src[nl+1:]
+	}
+	if nl := strings.IndexByte(src, '\n'); nl >= 0 {
+		src = src[:nl]
+	}
+	return strings.TrimRight(src, "\r")
+}
+
+// ExecError: template render edilirken bir ifade değerlendirilemediğinde dönen hata.
+// Template render edilen template'in ismi, Pos ise hatanın oluştuğu yerdir (extends veya
+// include ile başka bir dosyada olabilir). Asıl hata errors.Unwrap ile alınabilir.
+type ExecError struct {
+	Template string
+	Pos
+	Expr string
+	Err  error
+
+	file string // render edilen template'in resolve edilmiş yolu; Pos.File ile karşılaştırılır
+}
+
+func (e *ExecError) Error() string {
+	msg := fmt.Sprintf("%s: error evaluating %q: %v", e.Pos, e.Expr, e.Err)
+	if e.Template != "" && e.file != e.File {
+		msg += " (rendering " + e.Template + ")"
+	}
+	return msg
+}
+
+func (e *ExecError) Unwrap() error { return e.Err }
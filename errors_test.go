@@ -0,0 +1,37 @@
+package vingo
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseErrorPosition(t *testing.T) {
+	tests := []struct {
+		src       string
+		line, col int
+	}{
+		{"<{ /if }>", 1, 1},
+		{"a\n  <{ if x }>", 2, 3},
+		{"<p><{ a b }></p>", 1, 9},
+		{"x\n  <{ if user.age >= }>y<{ /if }>", 2, 20},
+		{"<{ for i in (xs }><{ /for }>", 1, 13},
+		{`<{ x | default("a) }>`, 1, 16},
+		{"<{ switch x }><{ case 1, ) }><{ /switch }>", 1, 26},
+		{"<{ set y = 1 + * 2 }>", 1, 16},
+		{"<{ with a b as c }><{ /with }>", 1, 11},
+		{"<{\n  a +\n  * b }>", 3, 3},
+		{"<{ 1x }>", 1, 5},
+	}
+	e := New(Options{})
+	for _, tt := range tests {
+		_, err := e.Compile("t.html", tt.src)
+		var pe *ParseError
+		if !errors.As(err, &pe) {
+			t.Errorf("Compile(%q) error = %v, want a *ParseError", tt.src, err)
+			continue
+		}
+		if pe.Line != tt.line || pe.Col != tt.col {
+			t.Errorf("Compile(%q) error at %d:%d, want %d:%d\n%v", tt.src, pe.Line, pe.Col, tt.line, tt.col, err)
+		}
+	}
+}
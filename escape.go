@@ -0,0 +1,780 @@
+package vingo
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"net/url"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// -------------------- Contextual auto-escaping --------------------
+//
+// Compile sırasında (extends zinciri çözüldükten sonra) template'in metin parçaları baştan sona
+// küçük bir HTML durum makinesinden geçirilir ve her çıktı etiketinin bulunduğu bağlam belirlenir:
+// HTML metni, etiket içi, attribute değeri, URL, <script>/on* (JavaScript) veya <style>/style (CSS).
+// Render sırasında değer o bağlama uygun kaçışla yazılır. html/template'teki gibi, if/switch
+// dalları aynı bağlamda bitmeli, for gövdesi de başladığı bağlamda bitmelidir.
+
+type escState uint8
+
+const (
+	stText        escState = iota // HTML metni
+	stTagName                     // "<a" etiket ismi okunurken
+	stTag                         // etiket içinde, attribute'lar arasında
+	stAttrName                    // attribute ismi okunurken
+	stAfterName                   // attribute isminden sonra
+	stBeforeValue                 // "=" sonrasında, değer başlamadan
+	stAttr                        // attribute değeri içinde
+	stRCDATA                      // <title> veya <textarea> içeriği
+	stScript                      // <script> içeriği
+	stStyle                       // <style> içeriği
+	stComment                     // <!-- ... --> içinde
+)
+
+var stateNames = [...]string{
+	stText:        "HTML text",
+	stTagName:     "a tag name",
+	stTag:         "a tag",
+	stAttrName:    "an attribute name",
+	stAfterName:   "an attribute name",
+	stBeforeValue: "an attribute value",
+	stAttr:        "an attribute value",
+	stRCDATA:      "a <title> or <textarea> element",
+	stScript:      "a <script> element",
+	stStyle:       "a <style> element",
+	stComment:     "an HTML comment",
+}
+
+// attrKind: attribute değerinin içeriği
+type attrKind uint8
+
+const (
+	attrPlain attrKind = iota
+	attrURL
+	attrJS
+	attrCSS
+)
+
+// jsState: JavaScript içinde bulunulan yer
+type jsState uint8
+
+const (
+	jsCode        jsState = iota
+	jsDQ                  // "..."
+	jsSQ                  // '...'
+	jsTemplate            // `...`
+	jsRegexp              // /.../
+	jsRegexpClass         // /[...]/ içindeki karakter sınıfı
+	jsLineComment
+	jsBlockComment
+)
+
+// jsKeywords: arkasından gelen "/" bir bölme değil regex başlangıcı olan anahtar kelimeler
+var jsKeywords = map[string]bool{
+	"return": true, "typeof": true, "instanceof": true, "in": true, "of": true, "new": true,
+	"delete": true, "void": true, "case": true, "do": true, "else": true, "throw": true,
+	"yield": true, "await": true,
+}
+
+// cssState: CSS içinde bulunulan yer
+type cssState uint8
+
+const (
+	cssCode cssState = iota
+	cssDQ
+	cssSQ
+	cssComment
+)
+
+// urlPart: URL attribute'unun neresinde olunduğu
+type urlPart uint8
+
+const (
+	urlStart urlPart = iota // henüz hiçbir şey yazılmadı; scheme burada belirlenir
+	urlPath                 // scheme/host/yol
+	urlQuery                // ? veya # sonrası
+)
+
+// escContext: metnin belli bir noktasındaki HTML bağlamı. Karşılaştırılabilir olduğu için
+// dalların aynı bağlamda bitip bitmediği == ile kontrol edilir.
+type escContext struct {
+	state   escState
+	element string // okunan veya içinde bulunulan etiketin ismi (küçük harf)
+	endTag  bool   // okunan etiket bir kapanış etiketi mi
+	attr    string // okunan attribute ismi (küçük harf)
+	kind    attrKind
+	delim   byte // attribute değerinin tırnağı; 0 ise tırnaksız
+	js      jsState
+	jsDiv   bool   // JavaScript kodunda sıradaki "/" bir bölme operatörü mü (değilse regex başlar)
+	jsTmpl  string // içinde bulunulan `${ ... }` ifadelerinin her biri için bir byte: açık { sayısı
+	css     cssState
+	url     urlPart
+}
+
+func (c escContext) String() string {
+	switch {
+	case c.state == stAttr && c.kind == attrURL:
+		return fmt.Sprintf("the URL attribute %q", c.attr)
+	case c.state == stAttr && c.kind == attrJS:
+		return fmt.Sprintf("the event handler attribute %q", c.attr)
+	case c.state == stAttr && c.kind == attrCSS:
+		return "a style attribute"
+	case c.state == stAttr:
+		return fmt.Sprintf("the attribute %q", c.attr)
+	case c.state == stTag:
+		return fmt.Sprintf("the <%s> tag", c.element)
+	case c.state == stAttrName || c.state == stAfterName:
+		return fmt.Sprintf("the attribute name %q", c.attr)
+	case c.state == stBeforeValue:
+		return fmt.Sprintf("the value of attribute %q", c.attr)
+	}
+	return stateNames[c.state]
+}
+
+// advance: bağlamı s metninin sonuna kadar ilerletir
+func (c escContext) advance(s string) escContext {
+	for i := 0; i < len(s); {
+		i += c.step(s[i:])
+	}
+	return c
+}
+
+// step: s'nin başındaki bir karakteri (veya "<!--" gibi bir işareti) işler ve tüketilen byte sayısını döner
+func (c *escContext) step(s string) int {
+	ch := s[0]
+	switch c.state {
+	case stText:
+		switch {
+		case strings.HasPrefix(s, "<!--"):
+			c.state = stComment
+			return 4
+		case len(s) > 2 && s[:2] == "</" && isASCIILetter(s[2]):
+			*c = escContext{state: stTagName, endTag: true}
+			return 2
+		case len(s) > 1 && ch == '<' && isASCIILetter(s[1]):
+			*c = escContext{state: stTagName}
+			return 1
+		}
+	case stTagName:
+		if !isASCIILetter(ch) && !isDigit(ch) && ch != '-' && ch != ':' {
+			c.state = stTag
+			return c.step(s)
+		}
+		c.element += string(toLowerASCII(ch))
+	case stTag:
+		switch {
+		case ch == '>':
+			c.closeTag()
+		case isHTMLSpace(ch) || ch == '/':
+		default:
+			c.state, c.attr = stAttrName, string(toLowerASCII(ch))
+		}
+	case stAttrName, stAfterName:
+		switch {
+		case ch == '=':
+			c.state, c.kind = stBeforeValue, attrKindOf(c.attr)
+		case ch == '>':
+			c.closeTag()
+		case isHTMLSpace(ch):
+			c.state = stAfterName
+		case ch == '/' || c.state == stAfterName:
+			c.state = stTag
+			return c.step(s)
+		default:
+			c.attr += string(toLowerASCII(ch))
+		}
+	case stBeforeValue:
+		switch {
+		case isHTMLSpace(ch):
+		case ch == '"' || ch == '\'':
+			c.enterAttr(ch)
+		case ch == '>':
+			c.closeTag()
+		default:
+			c.enterAttr(0)
+			return c.step(s)
+		}
+	case stAttr:
+		switch {
+		case c.delim != 0 && ch == c.delim, c.delim == 0 && isHTMLSpace(ch):
+			c.leaveAttr()
+		case c.delim == 0 && ch == '>':
+			c.closeTag()
+		default:
+			switch c.kind {
+			case attrJS:
+				return c.jsStep(s)
+			case attrCSS:
+				return c.cssStep(s)
+			case attrURL:
+				c.urlStep(ch)
+			}
+		}
+	case stRCDATA, stScript, stStyle:
+		if end := "</" + c.element; len(s) >= len(end) && strings.EqualFold(s[:len(end)], end) {
+			*c = escContext{state: stTagName, endTag: true}
+			return 2
+		}
+		switch c.state {
+		case stScript:
+			return c.jsStep(s)
+		case stStyle:
+			return c.cssStep(s)
+		}
+	case stComment:
+		if strings.HasPrefix(s, "-->") {
+			c.state = stText
+			return 3
+		}
+	}
+	return 1
+}
+
+// closeTag: ">" ile biten bir etiketten sonraki bağlam
+func (c *escContext) closeTag() {
+	el := c.element
+	if c.endTag {
+		el = ""
+	}
+	switch el {
+	case "script":
+		*c = escContext{state: stScript, element: el}
+	case "style":
+		*c = escContext{state: stStyle, element: el}
+	case "title", "textarea":
+		*c = escContext{state: stRCDATA, element: el}
+	default:
+		*c = escContext{state: stText}
+	}
+}
+
+func (c *escContext) enterAttr(delim byte) {
+	c.state, c.delim = stAttr, delim
+	c.js, c.jsDiv, c.jsTmpl, c.css, c.url = jsCode, false, "", cssCode, urlStart
+}
+
+func (c *escContext) leaveAttr() {
+	*c = escContext{state: stTag, element: c.element, endTag: c.endTag}
+}
+
+func (c *escContext) jsStep(s string) int {
+	ch := s[0]
+	switch c.js {
+	case jsCode:
+		return c.jsCodeStep(s)
+	case jsDQ, jsSQ, jsTemplate:
+		if ch == '\\' && len(s) > 1 {
+			return 2
+		}
+		if c.js == jsTemplate && strings.HasPrefix(s, "${") {
+			c.js, c.jsDiv, c.jsTmpl = jsCode, false, c.jsTmpl+"\x00"
+			return 2
+		}
+		if (c.js == jsDQ && ch == '"') || (c.js == jsSQ && ch == '\'') || (c.js == jsTemplate && ch == '`') {
+			c.js, c.jsDiv = jsCode, true
+		}
+	case jsRegexp, jsRegexpClass:
+		switch {
+		case ch == '\\' && len(s) > 1:
+			return 2
+		case ch == '[':
+			c.js = jsRegexpClass
+		case ch == ']' && c.js == jsRegexpClass:
+			c.js = jsRegexp
+		case ch == '/' && c.js == jsRegexp:
+			c.js, c.jsDiv = jsCode, true
+		}
+	case jsLineComment:
+		if ch == '\n' {
+			c.js = jsCode
+		}
+	case jsBlockComment:
+		if strings.HasPrefix(s, "*/") {
+			c.js = jsCode
+			return 2
+		}
+	}
+	return 1
+}
+
+// jsCodeStep: JavaScript kodunda bir token'ı işler. html/template'teki gibi "/"nin bölme mi
+// yoksa regex başlangıcı mı olduğu bir önceki token'a göre belirlenir: bir değerden (isim,
+// sayı, string, ")" veya "]") sonra bölme, bir operatörden veya anahtar kelimeden sonra regex.
+func (c *escContext) jsCodeStep(s string) int {
+	ch := s[0]
+	switch {
+	case ch == '"':
+		c.js = jsDQ
+	case ch == '\'':
+		c.js = jsSQ
+	case ch == '`':
+		c.js = jsTemplate
+	case strings.HasPrefix(s, "//"):
+		c.js = jsLineComment
+		return 2
+	case strings.HasPrefix(s, "/*"):
+		c.js = jsBlockComment
+		return 2
+	case ch == '/' && !c.jsDiv:
+		c.js = jsRegexp
+	case isJSIdent(ch):
+		n := 1
+		for n < len(s) && isJSIdent(s[n]) {
+			n++
+		}
+		c.jsDiv = !jsKeywords[s[:n]]
+		return n
+	case strings.HasPrefix(s, "++"), strings.HasPrefix(s, "--"):
+		c.jsDiv = true
+		return 2
+	case ch == '{' && c.jsTmpl != "":
+		top := len(c.jsTmpl) - 1
+		c.jsTmpl, c.jsDiv = c.jsTmpl[:top]+string([]byte{c.jsTmpl[top] + 1}), false
+	case ch == '}' && c.jsTmpl != "":
+		top := len(c.jsTmpl) - 1
+		if c.jsTmpl[top] == 0 {
+			// `${ ... }` ifadesi bitti, template literal devam ediyor
+			c.js, c.jsTmpl = jsTemplate, c.jsTmpl[:top]
+		} else {
+			c.jsTmpl, c.jsDiv = c.jsTmpl[:top]+string([]byte{c.jsTmpl[top] - 1}), false
+		}
+	case ch == ')' || ch == ']':
+		c.jsDiv = true
+	case !isHTMLSpace(ch):
+		c.jsDiv = false
+	}
+	return 1
+}
+
+func (c *escContext) cssStep(s string) int {
+	ch := s[0]
+	switch c.css {
+	case cssCode:
+		switch {
+		case ch == '"':
+			c.css = cssDQ
+		case ch == '\'':
+			c.css = cssSQ
+		case strings.HasPrefix(s, "/*"):
+			c.css = cssComment
+			return 2
+		}
+	case cssDQ, cssSQ:
+		if ch == '\\' && len(s) > 1 {
+			return 2
+		}
+		if (c.css == cssDQ && ch == '"') || (c.css == cssSQ && ch == '\'') {
+			c.css = cssCode
+		}
+	case cssComment:
+		if strings.HasPrefix(s, "*/") {
+			c.css = cssCode
+			return 2
+		}
+	}
+	return 1
+}
+
+func (c *escContext) urlStep(ch byte) {
+	switch {
+	case ch == '?' || ch == '#':
+		c.url = urlQuery
+	case c.url == urlStart && !isHTMLSpace(ch):
+		c.url = urlPath
+	}
+}
+
+// urlAttrs: değeri URL olan attribute'lar (isminde src, uri veya url geçenler de URL sayılır)
+var urlAttrs = map[string]bool{
+	"href": true, "action": true, "cite": true, "data": true, "formaction": true, "poster": true,
+	"background": true, "longdesc": true, "manifest": true, "codebase": true, "usemap": true,
+	"icon": true, "profile": true, "xmlns": true,
+}
+
+func attrKindOf(name string) attrKind {
+	// html/template'teki gibi data-* ve isim alanlı (xlink:href) attribute'lar yerel isimleriyle
+	// değerlendirilir; xmlns:* her zaman bir URL'dir
+	if strings.HasPrefix(name, "data-") {
+		name = name[len("data-"):]
+	} else if prefix, local, ok := strings.Cut(name, ":"); ok {
+		if prefix == "xmlns" {
+			return attrURL
+		}
+		name = local
+	}
+	switch {
+	case strings.HasPrefix(name, "on"):
+		return attrJS
+	case name == "style":
+		return attrCSS
+	case urlAttrs[name], strings.Contains(name, "src"), strings.Contains(name, "uri"), strings.Contains(name, "url"):
+		return attrURL
+	}
+	return attrPlain
+}
+
+// escaper: bu bağlamdaki bir çıktı etiketinin kaçış fonksiyonu ve etiketten sonraki bağlam
+func (c escContext) escaper() (Escaper, escContext) {
+	switch c.state {
+	case stText:
+		return func(v interface{}) (string, error) {
+			if h, ok := v.(HTML); ok {
+				return string(h), nil
+			}
+			return html.EscapeString(toString(v)), nil
+		}, c
+	case stRCDATA, stComment:
+		return stringEscaper(html.EscapeString), c
+	case stTagName, stTag, stAttrName, stAfterName:
+		after := c
+		if c.state == stTag || c.state == stAfterName {
+			after.state, after.attr = stAttrName, ""
+		}
+		return stringEscaper(filterAttrName), after
+	case stBeforeValue:
+		// tırnaksız bir attribute değeri bu etiketle başlıyor
+		c.enterAttr(0)
+		return c.escaper()
+	case stAttr:
+		var inner Escaper
+		after := c
+		switch c.kind {
+		case attrURL:
+			inner = urlEscaper(c.url)
+			if c.url == urlStart {
+				after.url = urlPath
+			}
+		case attrJS:
+			inner = jsEscaper(c.js)
+			after.jsDiv = after.jsDiv || c.js == jsCode
+		case attrCSS:
+			inner = cssEscaper(c.css)
+		default:
+			inner = stringEscaper(func(s string) string { return s })
+		}
+		outer := html.EscapeString
+		if c.delim == 0 {
+			outer = escapeUnquotedAttr
+		}
+		return func(v interface{}) (string, error) {
+			s, err := inner(v)
+			if err != nil {
+				return "", err
+			}
+			return outer(s), nil
+		}, after
+	case stScript:
+		// kodda yazılan değer bir literal'dir; arkasından gelen "/" bir bölmedir
+		after := c
+		after.jsDiv = c.jsDiv || c.js == jsCode
+		return jsEscaper(c.js), after
+	case stStyle:
+		return cssEscaper(c.css), c
+	}
+	return stringEscaper(html.EscapeString), c
+}
+
+func stringEscaper(fn func(string) string) Escaper {
+	return func(v interface{}) (string, error) { return fn(toString(v)), nil }
+}
+
+// unsafeOutput: bağlama güvenle konamayan değerlerin yerine yazılan işaret (html/template ile aynı)
+const unsafeOutput = "ZgotmplZ"
+
+var attrNamePattern = regexp.MustCompile(`^[A-Za-z0-9_:.-]*$`)
+
+// filterAttrName: etiket içine yazılan değerler sadece zararsız attribute isimleri olabilir;
+// on*, style veya URL attribute'ları gibi içeriği çalıştırılabilenler reddedilir.
+func filterAttrName(s string) string {
+	if !attrNamePattern.MatchString(s) || (s != "" && attrKindOf(strings.ToLower(s)) != attrPlain) {
+		return unsafeOutput
+	}
+	return s
+}
+
+// escapeUnquotedAttr: tırnaksız attribute değerini bitirebilecek karakterleri de kaçışlar
+func escapeUnquotedAttr(s string) string {
+	s = html.EscapeString(s)
+	var b strings.Builder
+	for _, r := range s {
+		switch r {
+		case ' ', '\t', '\n', '\r', '\f', '=', '`':
+			fmt.Fprintf(&b, "&#%d;", r)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+func urlEscaper(part urlPart) Escaper {
+	return func(v interface{}) (string, error) {
+		if u, ok := v.(URL); ok {
+			return normalizeURL(string(u)), nil
+		}
+		s := toString(v)
+		switch part {
+		case urlStart:
+			if !safeURLScheme(s) {
+				return "#" + unsafeOutput, nil
+			}
+			return normalizeURL(s), nil
+		case urlPath:
+			return normalizeURL(s), nil
+		}
+		return url.QueryEscape(s), nil
+	}
+}
+
+// safeURLScheme: URL göreli ise veya http, https ya da mailto scheme'i taşıyorsa true.
+// javascript: gibi scheme'ler bu yüzden bir href'in başına yazılamaz.
+func safeURLScheme(s string) bool {
+	i := strings.IndexAny(s, ":/?#")
+	if i < 0 || s[i] != ':' {
+		return true
+	}
+	switch strings.ToLower(s[:i]) {
+	case "http", "https", "mailto":
+		return true
+	}
+	return false
+}
+
+// normalizeURL: URL'de yeri olmayan karakterleri (boşluk, tırnak, < > ...) yüzde kodlamasıyla yazar;
+// URL'nin yapısını oluşturan karakterlere dokunmaz.
+func normalizeURL(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		ch := s[i]
+		if isASCIILetter(ch) || isDigit(ch) || strings.IndexByte("-._~!#$&'()*+,/:;=?@[]%", ch) >= 0 {
+			b.WriteByte(ch)
+			continue
+		}
+		fmt.Fprintf(&b, "%%%02X", ch)
+	}
+	return b.String()
+}
+
+func jsEscaper(state jsState) Escaper {
+	switch state {
+	case jsRegexp, jsRegexpClass:
+		return stringEscaper(escapeJSRegexp)
+	case jsDQ, jsSQ, jsTemplate, jsLineComment, jsBlockComment:
+		return stringEscaper(escapeJSString)
+	}
+	// kod içinde değer bir JavaScript literal'i olarak (JSON) yazılır: <{ user.name }> -> "Ana"
+	return func(v interface{}) (string, error) {
+		if j, ok := v.(JS); ok {
+			return string(j), nil
+		}
+		b, err := json.Marshal(v)
+		if err != nil {
+			return "", fmt.Errorf("cannot write %T as a JavaScript value: %v", v, err)
+		}
+		return string(b), nil
+	}
+}
+
+// escapeJSString: JavaScript string'i (veya yorumu) içinden çıkılmasını ve </script> ile
+// bitirilmesini engelleyecek şekilde kaçışlar
+func escapeJSString(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch r {
+		case '\\':
+			b.WriteString(`\\`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\t':
+			b.WriteString(`\t`)
+		case '"', '\'', '`', '<', '>', '&', '=', '$', '/', '\u2028', '\u2029':
+			fmt.Fprintf(&b, `\u%04x`, r)
+		default:
+			if r < 0x20 {
+				fmt.Fprintf(&b, `\u%04x`, r)
+			} else {
+				b.WriteRune(r)
+			}
+		}
+	}
+	return b.String()
+}
+
+// escapeJSRegexp: regex literal'i içinde değer, özel karakterleri kaçışlanmış bir metin olarak
+// eşleşir; boş değer regex'i bir yoruma (//) çevirmesin diye (?:) yazılır
+func escapeJSRegexp(s string) string {
+	if s == "" {
+		return "(?:)"
+	}
+	var b strings.Builder
+	for _, r := range s {
+		if strings.ContainsRune(`^$.*+?()[]{}|-`, r) {
+			b.WriteByte('\\')
+			b.WriteRune(r)
+			continue
+		}
+		b.WriteString(escapeJSString(string(r)))
+	}
+	return b.String()
+}
+
+var cssValuePattern = regexp.MustCompile(`^[\w\s.,#%+!-]*$`)
+
+func cssEscaper(state cssState) Escaper {
+	if state != cssCode {
+		return stringEscaper(escapeCSSString)
+	}
+	// CSS kodunda sadece renk, sayı, birim ve isim gibi zararsız değerlere izin verilir
+	return func(v interface{}) (string, error) {
+		if c, ok := v.(CSS); ok {
+			return string(c), nil
+		}
+		if s := toString(v); cssValuePattern.MatchString(s) {
+			return s, nil
+		}
+		return unsafeOutput, nil
+	}
+}
+
+// escapeCSSString: harf, rakam ve boşluk dışındaki karakterleri CSS kaçışıyla (\HEX ) yazar
+func escapeCSSString(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == ' ' || r == '-' || r == '_' || r == '.' || r == ',' {
+			b.WriteRune(r)
+			continue
+		}
+		fmt.Fprintf(&b, `\%x `, r)
+	}
+	return b.String()
+}
+
+func isASCIILetter(c byte) bool { return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') }
+
+func isDigit(c byte) bool { return c >= '0' && c <= '9' }
+
+// isJSIdent: JavaScript isimlerinde ve sayılarda bulunabilen karakterler
+func isJSIdent(c byte) bool {
+	return isASCIILetter(c) || isDigit(c) || c == '_' || c == '$' || c == '.' || c >= 0x80
+}
+
+func isHTMLSpace(c byte) bool { return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f' }
+
+func toLowerASCII(c byte) byte {
+	if c >= 'A' && c <= 'Z' {
+		return c + 'a' - 'A'
+	}
+	return c
+}
+
+// -------------------- Context analysis over the node tree --------------------
+
+// contextAnalyzer: derlenmiş bir template ağacını gezip her VarNode'a bağlamına uygun kaçış
+// fonksiyonunu atar. mode.esc verilmişse (HTML dışı template'ler) bağlam izlenmez ve her VarNode'a
+// mode.esc atanır. Include'lar da aynı kaçışla derlenir. errorf, hatanın konumundaki dosyanın
+// kaynağından alıntı yapar.
+type contextAnalyzer struct {
+	mode   escaping
+	errorf func(pos Pos, format string, args ...interface{}) error
+}
+
+func (a *contextAnalyzer) nodes(c escContext, nodes []Node) (escContext, error) {
+	var err error
+	for _, n := range nodes {
+		if c, err = a.node(c, n); err != nil {
+			return c, err
+		}
+	}
+	return c, nil
+}
+
+func (a *contextAnalyzer) node(c escContext, n Node) (escContext, error) {
+	switch n := n.(type) {
+	case *TextNode:
+		if a.mode.esc != nil {
+			return c, nil
+		}
+		return c.advance(n.Text), nil
+	case *VarNode:
+		if a.mode.esc != nil {
+			n.escape = a.mode.esc
+			return c, nil
+		}
+		if n.ctx != nil && *n.ctx != c {
+			return c, a.errorf(n.Pos, "%s is used both in %s and in %s", n.Name, n.ctx, c)
+		}
+		fn, after := c.escaper()
+		n.ctx, n.escape = &c, fn
+		return after, nil
+	case *IfNode:
+		bodies := make([][]Node, 0, len(n.Branches)+1)
+		for _, b := range n.Branches {
+			bodies = append(bodies, b.Body)
+		}
+		return a.branches(c, n.Pos, "if", append(bodies, n.Else))
+	case *SwitchNode:
+		bodies := make([][]Node, 0, len(n.Cases)+1)
+		for _, cs := range n.Cases {
+			bodies = append(bodies, cs.Body)
+		}
+		return a.branches(c, n.Pos, "switch", append(bodies, n.Default))
+	case *ForNode:
+		for _, body := range [][]Node{n.Body, n.Else} {
+			end, err := a.nodes(c, body)
+			if err != nil {
+				return c, err
+			}
+			if end != c {
+				return c, a.errorf(n.Pos, "for body starts in %s but ends in %s", c, end)
+			}
+		}
+		return c, nil
+	case *BlockNode:
+		b := n
+		if n.override != nil {
+			b = n.override
+		}
+		return a.nodes(c, b.Body)
+	case *SuperNode:
+		return a.nodes(c, n.Body)
+	case *WithNode:
+		return a.nodes(c, n.Body)
+	case *CaptureNode:
+		n.flat = a.mode.esc != nil
+		end, err := a.nodes(escContext{}, n.Body)
+		if err != nil {
+			return c, err
+		}
+		if end.state != stText {
+			return c, a.errorf(n.Pos, "capture %q ends in %s, not in HTML text", n.Name, end)
+		}
+		return c, nil
+	case *IncludeNode:
+		n.escaping = a.mode
+		if c.state != stText {
+			return c, a.errorf(n.Pos, "include cannot be used in %s, only in HTML text", c)
+		}
+	}
+	return c, nil
+}
+
+// branches: if/switch dallarının hepsi aynı bağlamda bitmeli
+func (a *contextAnalyzer) branches(c escContext, pos Pos, tag string, bodies [][]Node) (escContext, error) {
+	var end escContext
+	for i, body := range bodies {
+		e, err := a.nodes(c, body)
+		if err != nil {
+			return c, err
+		}
+		if i > 0 && e != end {
+			return c, a.errorf(pos, "branches of %s end in different contexts: %s and %s", tag, end, e)
+		}
+		end = e
+	}
+	return end, nil
+}
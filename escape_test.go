@@ -0,0 +1,161 @@
+package vingo
+
+import (
+	"strings"
+	"testing"
+)
+
+// renderString: src'yi e ile name ismiyle derleyip data ile render eder
+func renderString(t *testing.T, e *Engine, name, src string, data map[string]interface{}) string {
+	t.Helper()
+	tpl, err := e.Compile(name, src)
+	if err != nil {
+		t.Fatalf("Compile(%q): %v", src, err)
+	}
+	var b strings.Builder
+	if err := tpl.Execute(&b, data); err != nil {
+		t.Fatalf("Execute(%q): %v", src, err)
+	}
+	return b.String()
+}
+
+func TestContextualEscaping(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		x    interface{}
+		want string
+	}{
+		// HTML metni ve RCDATA
+		{"text", `<p><{ x }></p>`, `<a href="x">&'`, `<p>&lt;a href=&#34;x&#34;&gt;&amp;&#39;</p>`},
+		{"title", `<title><{ x }></title>`, `</title><script>`, `<title>&lt;/title&gt;&lt;script&gt;</title>`},
+		{"textarea", `<textarea><{ x }></textarea>`, `</textarea>`, `<textarea>&lt;/textarea&gt;</textarea>`},
+		{"after script", `<script></script><p><{ x }></p>`, `<b>`, `<script></script><p>&lt;b&gt;</p>`},
+
+		// attribute değerleri
+		{"double quoted attr", `<a title="<{ x }>">`, `" onclick="alert(1)`, `<a title="&#34; onclick=&#34;alert(1)">`},
+		{"single quoted attr", `<a title='<{ x }>'>`, `' a`, `<a title='&#39; a'>`},
+		{"unquoted attr", `<a title=<{ x }>>`, `a b=c`, `<a title=a&#32;b&#61;c>`},
+		{"unquoted attr after text", `<a title=t<{ x }>>`, "a\tb", `<a title=ta&#9;b>`},
+
+		// etiket içi: sadece zararsız attribute isimleri
+		{"attr name", `<input <{ x }>>`, `disabled`, `<input disabled>`},
+		{"attr name event", `<input <{ x }>>`, `onclick`, `<input ZgotmplZ>`},
+		{"attr name url", `<input <{ x }>>`, `src`, `<input ZgotmplZ>`},
+		{"attr name markup", `<input <{ x }>>`, `a="b"`, `<input ZgotmplZ>`},
+
+		// URL'ler
+		{"url javascript", `<a href="<{ x }>">`, `javascript:alert(1)`, `<a href="#ZgotmplZ">`},
+		{"url javascript mixed case", `<a href="<{ x }>">`, ` JavaScript:alert(1)`, `<a href="#ZgotmplZ">`},
+		{"url data", `<img src="<{ x }>">`, `data:text/html,<b>`, `<img src="#ZgotmplZ">`},
+		{"url https", `<a href="<{ x }>">`, `https://example.com/a b?q="1"`, `<a href="https://example.com/a%20b?q=%221%22">`},
+		{"url relative", `<a href="<{ x }>">`, `/p?q=1&r=2`, `<a href="/p?q=1&amp;r=2">`},
+		{"url path", `<a href="/u/<{ x }>">`, `javascript:a"b`, `<a href="/u/javascript:a%22b">`},
+		{"url query", `<a href="/s?q=<{ x }>">`, `a&b c`, `<a href="/s?q=a%26b+c">`},
+		{"url fragment", `<a href="/p#<{ x }>">`, `a b`, `<a href="/p#a+b">`},
+		{"url unquoted", `<a href=<{ x }>>`, `/a b`, `<a href=/a%20b>`},
+		{"url namespaced attr", `<svg><a xlink:href="<{ x }>">`, `javascript:alert(1)`, `<svg><a xlink:href="#ZgotmplZ">`},
+		{"url xmlns attr", `<svg xmlns:v="<{ x }>">`, `javascript:alert(1)`, `<svg xmlns:v="#ZgotmplZ">`},
+		{"attr name namespaced", `<a <{ x }>>`, `xlink:href`, `<a ZgotmplZ>`},
+		{"url attr by name", `<div data-url="<{ x }>">`, `javascript:x`, `<div data-url="#ZgotmplZ">`},
+
+		// JavaScript
+		{"script code string", `<script>var v = <{ x }>;</script>`, `</script>`, `<script>var v = "\u003c/script\u003e";</script>`},
+		{"script code value", `<script>var v = <{ x }>;</script>`, []int{1, 2}, `<script>var v = [1,2];</script>`},
+		{"script code nil", `<script>var v = <{ x }>;</script>`, nil, `<script>var v = null;</script>`},
+		{"js double quoted", `<script>var s = "<{ x }>";</script>`, `"</script>`, `<script>var s = "\u0022\u003c\u002fscript\u003e";</script>`},
+		{"js single quoted", `<script>var s = '<{ x }>';</script>`, `'\`, `<script>var s = '\u0027\\';</script>`},
+		{"js template literal", "<script>var s = `<{ x }>`;</script>", "${alert(1)}`", "<script>var s = `\\u0024{alert(1)}\\u0060`;</script>"},
+		{"js after string", `<script>var s = "a"; var v = <{ x }>;</script>`, `b`, `<script>var s = "a"; var v = "b";</script>`},
+		{"js escaped quote", `<script>var s = "a\"<{ x }>";</script>`, `b`, `<script>var s = "a\"b";</script>`},
+		{"js line comment", "<script>// <{ x }>\n</script>", "\nalert(1)", "<script>// \\nalert(1)\n</script>"},
+		{"js after regexp", `<script>var s = t.replace(/"/g, "'"); var v = <{ x }>;</script>`, `alert(1)`, `<script>var s = t.replace(/"/g, "'"); var v = "alert(1)";</script>`},
+		{"js regexp class", `<script>var r = /[/"]/; var v = <{ x }>;</script>`, `alert(1)`, `<script>var r = /[/"]/; var v = "alert(1)";</script>`},
+		{"js regexp after keyword", `<script>function f() { return /'/.test(<{ x }>) }</script>`, `a`, `<script>function f() { return /'/.test("a") }</script>`},
+		{"js division", `<script>var v = a / 2; var s = "<{ x }>";</script>`, `"`, `<script>var v = a / 2; var s = "\u0022";</script>`},
+		{"js division after output", `<script>var v = <{ x }> / 2; var s = "<{ x }>";</script>`, 4, `<script>var v = 4 / 2; var s = "4";</script>`},
+		{"js in regexp", `<script>var r = /<{ x }>/;</script>`, `a.b/`, `<script>var r = /a\.b\u002f/;</script>`},
+		{"js in regexp empty", `<script>var r = /<{ x }>/;</script>`, ``, `<script>var r = /(?:)/;</script>`},
+		{"js template expression", "<script>var s = `${<{ x }>}`;</script>", `alert(1)`, "<script>var s = `${\"alert(1)\"}`;</script>"},
+		{"js template after expression", "<script>var s = `${ {a: 1}.a }<{ x }>`;</script>", "${a}", "<script>var s = `${ {a: 1}.a }\\u0024{a}`;</script>"},
+		{"js nested template", "<script>var s = `a${ `b${c}` }${<{ x }>}`;</script>", `c`, "<script>var s = `a${ `b${c}` }${\"c\"}`;</script>"},
+		{"event handler", `<button onclick="f(<{ x }>)">`, `a"b`, `<button onclick="f(&#34;a\&#34;b&#34;)">`},
+		{"event handler string", `<button onclick="f('<{ x }>')">`, `a'b`, `<button onclick="f('a\u0027b')">`},
+
+		// CSS
+		{"style code", `<style>p { color: <{ x }> }</style>`, `red`, `<style>p { color: red }</style>`},
+		{"style code unsafe", `<style>p { color: <{ x }> }</style>`, `red; } body { x: y`, `<style>p { color: ZgotmplZ }</style>`},
+		{"css string", `<style>p::after { content: "<{ x }>" }</style>`, `a"b`, `<style>p::after { content: "a\22 b" }</style>`},
+		{"style attr", `<p style="color: <{ x }>">`, `#fff`, `<p style="color: #fff">`},
+		{"style attr unsafe", `<p style="width: <{ x }>">`, `expression(alert(1))`, `<p style="width: ZgotmplZ">`},
+
+		// güvenilir değerler sadece kendi bağlamlarında
+		{"trusted html text", `<p><{ x }></p>`, HTML(`<b>hi</b>`), `<p><b>hi</b></p>`},
+		{"trusted html attr", `<a title="<{ x }>">`, HTML(`<b>`), `<a title="&lt;b&gt;">`},
+		{"trusted url", `<a href="<{ x }>">`, URL(`javascript:void(0)`), `<a href="javascript:void(0)">`},
+		{"trusted js", `<script><{ x }></script>`, JS(`f()`), `<script>f()</script>`},
+		{"trusted css", `<p style="<{ x }>">`, CSS(`color: red`), `<p style="color: red">`},
+	}
+	e := New(Options{})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := renderString(t, e, "t.html", tt.src, map[string]interface{}{"x": tt.x})
+			if got != tt.want {
+				t.Errorf("%s\n got: %s\nwant: %s", tt.src, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestContextualEscapingErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{"if branches", `<a <{ if ok }>href="<{ /if }>">`, "branches of if end in different contexts"},
+		{"if else branches", `<{ if ok }><script><{ else }><p><{ /if }>`, "branches of if end in different contexts"},
+		{"switch branches", `<{ switch x }><{ case 1 }><style><{ case 2 }>b<{ /switch }>`, "branches of switch end in different contexts"},
+		{"for body", `<{ for i in items }><a href="<{ /for }>">`, "for body starts in HTML text but ends in"},
+		{"for else", `<{ for i in items }>a<{ else }><p title="<{ /for }>">`, "for body starts in HTML text but ends in"},
+		{"include in attr", `<a href="<{ include "x.html" }>">`, "include cannot be used in"},
+		{"capture", `<{ capture c }><a title="<{ /capture }>">`, "ends in the attribute"},
+	}
+	e := New(Options{})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := e.Compile("t.html", tt.src)
+			if err == nil {
+				t.Fatalf("Compile(%q) succeeded, want error containing %q", tt.src, tt.want)
+			}
+			if _, ok := err.(*ParseError); !ok {
+				t.Errorf("Compile(%q) error is %T, want *ParseError", tt.src, err)
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Compile(%q) error = %q, want it to contain %q", tt.src, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestContextualEscapingBalanced(t *testing.T) {
+	// dallar ve döngü gövdeleri aynı bağlamda bittiği sürece derlenir
+	e := New(Options{})
+	src := `<a <{ if ok }>href="/a"<{ else }>href="/b"<{ /if }>>` +
+		`<{ for i in items }><li title="<{ i }>"><{ i }></li><{ /for }>`
+	got := renderString(t, e, "t.html", src, map[string]interface{}{"ok": true, "items": []string{`"`, `<`}})
+	want := `<a href="/a"><li title="&#34;">&#34;</li><li title="&lt;">&lt;</li>`
+	if got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
+
+func TestNoAutoEscape(t *testing.T) {
+	e := New(Options{NoAutoEscape: true})
+	src := `<p><{ x }></p><script>var s = "<{ x }>";</script>`
+	got := renderString(t, e, "t.html", src, map[string]interface{}{"x": `<b>"`})
+	want := `<p><b>"</p><script>var s = "<b>"";</script>`
+	if got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
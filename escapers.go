@@ -0,0 +1,148 @@
+package vingo
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// -------------------- Escapers for non-HTML output --------------------
+
+// Escaper: bir değeri çıktı biçiminde güvenle yazılabilecek metne çevirir. HTML template'lerinde
+// kaçış bağlama göre seçilir; diğer biçimlerde template'in uzantısına kayıtlı Escaper bütün
+// çıktı etiketlerine uygulanır.
+type Escaper func(v interface{}) (string, error)
+
+// builtinEscapers: her yeni Engine'e kopyalanan uzantı -> Escaper tablosu. Burada olmayan
+// uzantılar (.html, .vgo, .vingo ...) HTML olarak bağlama göre kaçışlanır.
+var builtinEscapers = map[string]Escaper{
+	".txt":  NoEscape,
+	".md":   NoEscape,
+	".json": EscapeJSON,
+	".xml":  EscapeXML,
+	".svg":  EscapeXML,
+	".sh":   EscapeShell,
+	".yaml": EscapeConfig,
+	".yml":  EscapeConfig,
+	".toml": EscapeConfig,
+}
+
+// NoEscape: değeri olduğu gibi yazar (.txt ve .md template'leri)
+func NoEscape(v interface{}) (string, error) {
+	return toString(v), nil
+}
+
+// EscapeJSON: değeri bir JSON string'inin içine yazılabilecek şekilde kaçışlar; tırnakları
+// template yazar: {"name": "<{ user.Name }>"}
+func EscapeJSON(v interface{}) (string, error) {
+	b, err := json.Marshal(toString(v))
+	if err != nil {
+		return "", err
+	}
+	return string(b[1 : len(b)-1]), nil
+}
+
+var xmlReplacer = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", `"`, "&#34;", "'", "&#39;")
+
+// EscapeXML: XML metni ve attribute değerleri için kaçışlar (.xml ve .svg template'leri)
+func EscapeXML(v interface{}) (string, error) {
+	return xmlReplacer.Replace(toString(v)), nil
+}
+
+var shellSafe = regexp.MustCompile(`^[\w@%+=:,./-]+$`)
+
+// EscapeShell: değeri tek bir shell argümanı olarak yazar; gerekirse tek tırnak içine alır: a b -> 'a b'
+func EscapeShell(v interface{}) (string, error) {
+	s := toString(v)
+	if shellSafe.MatchString(s) {
+		return s, nil
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'", nil
+}
+
+// EscapeConfig: YAML ve TOML için değer yazar. Sayılar ve bool'lar olduğu gibi, diğer her şey
+// iki biçimde de geçerli olan çift tırnaklı bir string olarak yazılır: port: <{ port }> -> port: 8080,
+// name: <{ name }> -> name: "web: 1"
+func EscapeConfig(v interface{}) (string, error) {
+	switch t := v.(type) {
+	case bool, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		return toString(t), nil
+	}
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range toString(v) {
+		switch r {
+		case '"':
+			b.WriteString(`\"`)
+		case '\\':
+			b.WriteString(`\\`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\t':
+			b.WriteString(`\t`)
+		default:
+			if r < 0x20 || r == 0x7f {
+				fmt.Fprintf(&b, `\u%04x`, r)
+			} else {
+				b.WriteRune(r)
+			}
+		}
+	}
+	b.WriteByte('"')
+	return b.String(), nil
+}
+
+// extPattern: RegisterEscaper'a verilebilecek uzantılar
+var extPattern = regexp.MustCompile(`^\.\w+$`)
+
+// RegisterEscaper: ext uzantılı (örneğin ".csv") template'lerin çıktılarına esc'i uygular;
+// yerleşik uzantıların kaçışı da böyle değiştirilebilir. esc nil ise uzantı yeniden HTML
+// olarak kaçışlanır. Geçersiz bir uzantı verilirse panic olur. Cache'teki template'ler
+// etkilenmediği için kaçışlar template'ler derlenmeden önce kaydedilmelidir.
+func (e *Engine) RegisterEscaper(ext string, esc Escaper) {
+	if !extPattern.MatchString(ext) {
+		panic(fmt.Sprintf("vingo: invalid extension %q", ext))
+	}
+	e.mu.Lock()
+	if esc == nil {
+		delete(e.escapers, strings.ToLower(ext))
+	} else {
+		e.escapers[strings.ToLower(ext)] = esc
+	}
+	e.mu.Unlock()
+}
+
+// escaping: bir template'in çıktılarının nasıl kaçışlandığı; esc nil ise HTML bağlamına göre.
+// key cache anahtarının parçasıdır: include edilen template, include eden template'in kaçışıyla
+// derlenir ve aynı dosya farklı kaçışlarla include edilirse her biri ayrı saklanır. cache nil
+// değilse (CompileWith'e verilen bir Escaper) include'lar engine'in cache'i yerine orada saklanır.
+type escaping struct {
+	key   string
+	esc   Escaper
+	cache *templateCache
+}
+
+// templateCache: CompileWith ile derlenen bir template'in include'larının cache'i
+type templateCache struct {
+	mu sync.RWMutex
+	m  map[string]*Template
+}
+
+// escapingFor: name template'inin uzantısına kayıtlı kaçış; kayıtlı değilse HTML
+func (e *Engine) escapingFor(name string) escaping {
+	if !e.autoEscape {
+		return escaping{}
+	}
+	ext := strings.ToLower(filepath.Ext(name))
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	if esc, ok := e.escapers[ext]; ok {
+		return escaping{key: ext, esc: esc}
+	}
+	return escaping{key: "html"}
+}
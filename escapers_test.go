@@ -0,0 +1,134 @@
+package vingo
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestBuiltinEscapers(t *testing.T) {
+	tests := []struct {
+		name string
+		esc  Escaper
+		in   interface{}
+		want string
+	}{
+		{"none", NoEscape, `<a href="x">`, `<a href="x">`},
+		{"json", EscapeJSON, "a\"b\\c\n<d>&", `a\"b\\c\n\u003cd\u003e\u0026`},
+		{"json number", EscapeJSON, 42, `42`},
+		{"xml", EscapeXML, `<a b="c" d='e'>&`, `&lt;a b=&#34;c&#34; d=&#39;e&#39;&gt;&amp;`},
+		{"shell safe", EscapeShell, `/usr/bin/env`, `/usr/bin/env`},
+		{"shell space", EscapeShell, `a b`, `'a b'`},
+		{"shell quote", EscapeShell, `it's; rm -rf /`, `'it'\''s; rm -rf /'`},
+		{"shell empty", EscapeShell, ``, `''`},
+		{"config int", EscapeConfig, 8080, `8080`},
+		{"config bool", EscapeConfig, true, `true`},
+		{"config float", EscapeConfig, 1.5, `1.5`},
+		{"config string", EscapeConfig, "web: 1", `"web: 1"`},
+		{"config numeric string", EscapeConfig, "8080", `"8080"`},
+		{"config control", EscapeConfig, "a\"b\\c\n\x01", `"a\"b\\c\n\u0001"`},
+	}
+	for _, tt := range tests {
+		got, err := tt.esc(tt.in)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s(%q) = %s, want %s", tt.name, tt.in, got, tt.want)
+		}
+	}
+}
+
+// formatFS: farklı uzantılı template'ler ve birbirini include edenler
+var formatFS = fstest.MapFS{
+	"page.html":  {Data: []byte(`<p><{ include "part.txt" }></p>`)},
+	"part.txt":   {Data: []byte(`<{ x }>`)},
+	"mail.txt":   {Data: []byte(`<{ include "card.html" }>`)},
+	"card.html":  {Data: []byte(`<b><{ x }></b>`)},
+	"data.json":  {Data: []byte(`{"x": "<{ x }>", "raw": <{ j | raw }>, "c": "<{ capture c }><{ x }><{ /capture }><{ c }>"}`)},
+	"feed.xml":   {Data: []byte(`<t a="<{ x }>"><{ x }><{ j | raw }></t>`)},
+	"run.sh":     {Data: []byte(`echo <{ x }>`)},
+	"app.yaml":   {Data: []byte("name: <{ x }>\nport: <{ n }>")},
+	"safe.json":  {Data: []byte(`"<{ h }>"`)},
+	"upper.html": {Data: []byte(`<{ include "part.txt" }>`)},
+}
+
+func TestOutputFormats(t *testing.T) {
+	data := map[string]interface{}{"x": `<a "b">`, "j": `{"k":1}`, "n": 8080, "h": HTML(`<b>"`)}
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"page.html", `<p>&lt;a &#34;b&#34;&gt;</p>`},
+		{"part.txt", `<a "b">`},
+		{"mail.txt", `<b><a "b"></b>`},
+		{"card.html", `<b>&lt;a &#34;b&#34;&gt;</b>`},
+		{"data.json", `{"x": "\u003ca \"b\"\u003e", "raw": {"k":1}, "c": "\u003ca \"b\"\u003e"}`},
+		{"feed.xml", `<t a="&lt;a &#34;b&#34;&gt;">&lt;a &#34;b&#34;&gt;{"k":1}</t>`},
+		{"run.sh", `echo '<a "b">'`},
+		{"app.yaml", "name: \"<a \\\"b\\\">\"\nport: 8080"},
+		{"safe.json", `"\u003cb\u003e\""`},
+	}
+	e := New(Options{FS: formatFS})
+	for _, tt := range tests {
+		got, err := e.Render(tt.name, data)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s\n got: %s\nwant: %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestRegisterEscaper(t *testing.T) {
+	e := New(Options{FS: formatFS})
+	e.RegisterEscaper(".txt", func(v interface{}) (string, error) { return strings.ToUpper(toString(v)), nil })
+	e.RegisterEscaper(".html", EscapeJSON)
+	got, err := e.Render("part.txt", map[string]interface{}{"x": "a<b"})
+	if err != nil || got != "A<B" {
+		t.Errorf("part.txt = %q, %v; want %q", got, err, "A<B")
+	}
+	// include'lar kendi uzantılarına göre değil include eden template'e göre kaçışlanır
+	got, err = e.Render("upper.html", map[string]interface{}{"x": `a"b`})
+	if err != nil || got != `a\"b` {
+		t.Errorf("upper.html = %q, %v; want %q", got, err, `a\"b`)
+	}
+
+	e.RegisterEscaper(".txt", nil)
+	got, err = e.Render("run.sh", map[string]interface{}{"x": "a b"})
+	if err != nil || got != "echo 'a b'" {
+		t.Errorf("run.sh = %q, %v", got, err)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("RegisterEscaper with an invalid extension did not panic")
+		}
+	}()
+	e.RegisterEscaper("csv", NoEscape)
+}
+
+func TestCompileWithIncludeCache(t *testing.T) {
+	e := New(Options{FS: formatFS})
+	upper := func(v interface{}) (string, error) { return strings.ToUpper(toString(v)), nil }
+	for i := 0; i < 50; i++ {
+		tpl, err := e.CompileWith("page.html", `[<{ include "part.txt" }>]`, upper)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var b strings.Builder
+		if err := tpl.Execute(&b, map[string]interface{}{"x": "a<b"}); err != nil {
+			t.Fatal(err)
+		}
+		if b.String() != "[A<B]" {
+			t.Fatalf("got %q, want %q", b.String(), "[A<B]")
+		}
+	}
+	// CompileWith'in include'ları engine'in cache'ini büyütmez
+	if n := len(e.cache); n != 0 {
+		t.Errorf("engine cache has %d entries after CompileWith, want 0", n)
+	}
+}
@@ -2,185 +2,13 @@ package vingo
 
 import (
 	"fmt"
+	"math"
 	"reflect"
-	"regexp"
 	"strconv"
 	"strings"
 )
 
-// -------------------- Expression Evaluator (basit) --------------------
-//
-// Supports:
-// - Comparisons: ==, !=, >, <, >=, <=
-// - Logical: and, or (left-to-right, no operator precedence beyond that)
-// - Parentheses not supported in this simple evaluator (could be added)
-// - Left and right operands can be identifiers (dot notation), quoted strings, numbers, booleans.
-
-var compOpRe = regexp.MustCompile(`\s*(==|!=|>=|<=|>|<)\s*`)
-
-func evalCondition(expr string, data map[string]interface{}) (bool, error) {
-	// split by " and " / " or " preserving order
-	// implement left-to-right evaluation
-	tokens := splitLogical(expr)
-	if len(tokens) == 0 {
-		// treat empty as false
-		return false, nil
-	}
-	// tokens like: [cond, op, cond, op, cond...], where op is "and"/"or"
-	// evaluate first cond
-	res, err := evalSimpleCond(strings.TrimSpace(tokens[0]), data)
-	if err != nil {
-		return false, err
-	}
-	i := 1
-	for i < len(tokens)-0 {
-		op := strings.TrimSpace(tokens[i])
-		nextExpr := strings.TrimSpace(tokens[i+1])
-		nextRes, err := evalSimpleCond(nextExpr, data)
-		if err != nil {
-			return false, err
-		}
-		if op == "and" {
-			res = res && nextRes
-		} else if op == "or" {
-			res = res || nextRes
-		} else {
-			return false, fmt.Errorf("unknown logical operator %s", op)
-		}
-		i += 2
-		if i >= len(tokens) {
-			break
-		}
-	}
-	return res, nil
-}
-
-func splitLogical(expr string) []string {
-	// naive split: find " and " and " or " tokens
-	parts := []string{}
-	cur := ""
-	low := strings.TrimSpace(expr)
-	words := strings.Fields(low)
-	// rebuild by scanning tokens
-	i := 0
-	for i < len(words) {
-		w := words[i]
-		if w == "and" || w == "or" {
-			parts = append(parts, strings.TrimSpace(cur))
-			parts = append(parts, w)
-			cur = ""
-		} else {
-			if cur == "" {
-				cur = w
-			} else {
-				cur += " " + w
-			}
-		}
-		i++
-	}
-	if cur != "" {
-		parts = append(parts, strings.TrimSpace(cur))
-	}
-	return parts
-}
-
-func evalSimpleCond(cond string, data map[string]interface{}) (bool, error) {
-	// If condition contains comparison operator -> split
-	if compOpRe.MatchString(cond) {
-		// loc := compOpRe.FindStringIndex(cond)
-		op := compOpRe.FindStringSubmatch(cond)[1]
-		parts := compOpRe.Split(cond, 2)
-		if len(parts) != 2 {
-			return false, fmt.Errorf("invalid comparison in '%s'", cond)
-		}
-		left := strings.TrimSpace(parts[0])
-		right := strings.TrimSpace(parts[1])
-		lv, lok := lookup(data, left)
-		if !lok {
-			// try literal
-			lv = literalFromString(left)
-		}
-		rv, rok := lookup(data, right)
-		if !rok {
-			rv = literalFromString(right)
-		}
-		return compareValues(lv, rv, op)
-	}
-	// no operator => truthy check of the expression (variable or literal)
-	v, ok := lookup(data, cond)
-	if ok {
-		return condTruthy(v), nil
-	}
-	// maybe it's literal
-	v2 := literalFromString(cond)
-	return condTruthy(v2), nil
-}
-
-func evalConditionWithValue(condExpr string, value interface{}, data map[string]interface{}) (bool, error) {
-	// prepare temporary data where lookups can reference __switch__
-	tmp := shallowCopyMap(data)
-	tmp["__switch__"] = value
-
-	s := strings.TrimSpace(condExpr)
-	if s == "" {
-		return false, nil
-	}
-
-	// shorthand: a single dot or "value" means direct equality to the switch value
-	if s == "." || s == "value" || s == "__switch__" {
-		// truthy check of value
-		return condTruthy(value), nil
-	}
-
-	// support comma-separated cases: e.g. "a, b, 3"
-	if strings.Contains(s, ",") {
-		parts := strings.Split(s, ",")
-		for _, p := range parts {
-			p = strings.TrimSpace(p)
-			// try literal equality first
-			lit := literalFromString(p)
-			ok, err := compareValues(value, lit, "==")
-			if err == nil && ok {
-				return true, nil
-			}
-			// fallback: try evaluating as an expression (can use __switch__ inside)
-			res, err := evalCondition(p, tmp)
-			if err == nil && res {
-				return true, nil
-			}
-		}
-		return false, nil
-	}
-
-	// If the case contains a comparison operator, evaluate it with __switch__ available.
-	if compOpRe.MatchString(s) {
-		// let evalCondition handle lookups like "__switch__ > 5" or ". > 5" if user writes that
-		// but replace single "." with __switch__ in expression for convenience:
-		expr := strings.ReplaceAll(s, ".", "__switch__")
-		return evalCondition(expr, tmp)
-	}
-
-	// No operator & no comma: treat as simple literal or identifier.
-	// Try literal equality first (numbers/strings/bool)
-	lit := literalFromString(s)
-	ok, err := compareValues(value, lit, "==")
-	if err == nil && ok {
-		return true, nil
-	}
-	// stringified compare
-	if fmt.Sprintf("%v", value) == fmt.Sprintf("%v", lit) {
-		return true, nil
-	}
-	// finally try evaluating the expression with __switch__ available (covers cases where
-	// condExpr is something like "__switch__ == 5" or complex lookup)
-	// also support shorthand where user used '.' inside expression
-	expr := strings.ReplaceAll(s, ".", "__switch__")
-	res, err := evalCondition(expr, tmp)
-	if err == nil {
-		return res, nil
-	}
-	return false, nil
-}
+// -------------------- Values: literals, comparison, truthiness, fields --------------------
 
 func literalFromString(s string) interface{} {
 	s = strings.TrimSpace(s)
@@ -262,6 +90,86 @@ func compareValues(a interface{}, b interface{}, op string) (bool, error) {
 	return false, fmt.Errorf("unsupported comparison between %T and %T", a, b)
 }
 
+// arith: iki değer arasında + - * / % işlemi. İki tam sayı arasındaki işlemler tam sayı kalır
+// (bölme dahil), bir tarafı ondalıklı olanlar float64 ile yapılır; iki string'in toplamı birleştirmedir.
+func arith(op string, a, b interface{}) (interface{}, error) {
+	if op == "+" {
+		if as, ok := a.(string); ok {
+			if bs, ok := b.(string); ok {
+				return as + bs, nil
+			}
+		}
+	}
+	ai, aInt := toInt(a)
+	bi, bInt := toInt(b)
+	if aInt && bInt {
+		switch op {
+		case "+":
+			return int(ai + bi), nil
+		case "-":
+			return int(ai - bi), nil
+		case "*":
+			return int(ai * bi), nil
+		case "/", "%":
+			if bi == 0 {
+				return nil, fmt.Errorf("integer division by zero")
+			}
+			if op == "/" {
+				return int(ai / bi), nil
+			}
+			return int(ai % bi), nil
+		}
+	}
+	af, aNum := toNumber(a)
+	bf, bNum := toNumber(b)
+	if !aNum || !bNum {
+		return nil, fmt.Errorf("invalid operation: %v %s %v (mismatched types %T and %T)", a, op, b, a, b)
+	}
+	switch op {
+	case "+":
+		return af + bf, nil
+	case "-":
+		return af - bf, nil
+	case "*":
+		return af * bf, nil
+	case "/":
+		if bf == 0 {
+			return nil, fmt.Errorf("division by zero")
+		}
+		return af / bf, nil
+	case "%":
+		if bf == 0 {
+			return nil, fmt.Errorf("division by zero")
+		}
+		return math.Mod(af, bf), nil
+	}
+	return nil, fmt.Errorf("unknown operator %s", op)
+}
+
+// toInt: tam sayı türlerini (string'ler hariç) int64'e çevirir
+func toInt(v interface{}) (int64, bool) {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int(), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return int64(rv.Uint()), true
+	}
+	return 0, false
+}
+
+// toNumber: sayı türlerini (string'ler hariç) float64'e çevirir; aritmetikte kullanılır
+func toNumber(v interface{}) (float64, bool) {
+	if i, ok := toInt(v); ok {
+		return float64(i), true
+	}
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Float32 || rv.Kind() == reflect.Float64 {
+		return rv.Float(), true
+	}
+	return 0, false
+}
+
 func toFloat(v interface{}) (float64, bool) {
 	switch t := v.(type) {
 	case int:
@@ -306,6 +214,9 @@ func condTruthy(v interface{}) bool {
 		return false
 	}
 	switch t := v.(type) {
+	case undefined:
+		// bulunamayan bir değişken false'tur: <{ if user.IsAdmni }> sessizce true olmaz
+		return false
 	case bool:
 		return t
 	case string:
@@ -317,11 +228,13 @@ func condTruthy(v interface{}) bool {
 	case float32, float64:
 		return reflect.ValueOf(v).Float() != 0
 	default:
-		// slices/maps: non-empty => true
+		// slices/maps: non-empty => true; nil pointers => false
 		rv := reflect.ValueOf(v)
 		switch rv.Kind() {
 		case reflect.Slice, reflect.Array, reflect.Map:
 			return rv.Len() > 0
+		case reflect.Ptr, reflect.Interface, reflect.Func, reflect.Chan:
+			return !rv.IsNil()
 		default:
 			return true
 		}
@@ -330,360 +243,179 @@ func condTruthy(v interface{}) bool {
 
 // -------------------- Helpers / utilities --------------------
 
-// lookup: dot notation support for map/struct
-func lookup(data map[string]interface{}, path string) (interface{}, bool) {
-	// if path is literal string "..." or number or boolean, don't treat as lookup
-	p := strings.TrimSpace(path)
-	if p == "" {
-		return nil, false
-	}
-	// quoted string?
-	if (strings.HasPrefix(p, "\"") && strings.HasSuffix(p, "\"")) || (strings.HasPrefix(p, "'") && strings.HasSuffix(p, "'")) {
-		unq, err := strconv.Unquote(p)
-		if err == nil {
-			return unq, true
-		}
-	}
-	// numeric literal?
-	if i, err := strconv.Atoi(p); err == nil {
-		return i, true
-	}
-	if f, err := strconv.ParseFloat(p, 64); err == nil {
-		return f, true
-	}
-	if p == "true" {
-		return true, true
-	}
-	if p == "false" {
-		return false, true
-	}
-
-	var cur interface{} = data
-	parts := strings.Split(p, ".")
-	for _, seg := range parts {
-		switch node := cur.(type) {
-		case map[string]interface{}:
-			v, ok := node[seg]
-			if !ok {
-				return nil, false
+// field: dot notation'ın tek adımı. Bir map anahtarını, struct alanını (gömülü struct'lardan
+// gelenler dahil, isimler structField'daki kurallarla) veya argümansız bir metodun sonucunu okur.
+// Pointer ve interface'ler otomatik olarak çözülür; dönen hata sadece çağrılan metottan gelir.
+func (e *Engine) field(cur interface{}, seg string) (interface{}, bool, error) {
+	if node, ok := cur.(map[string]interface{}); ok {
+		v, ok := node[seg]
+		return v, ok, nil
+	}
+	rv := indirect(reflect.ValueOf(cur))
+	switch rv.Kind() {
+	case reflect.Map:
+		if rv.Type().Key().Kind() == reflect.String {
+			mv := rv.MapIndex(reflect.ValueOf(seg).Convert(rv.Type().Key()))
+			if mv.IsValid() {
+				return mv.Interface(), true, nil
 			}
-			cur = v
-		default:
-			rv := reflect.ValueOf(cur)
-			switch rv.Kind() {
-			case reflect.Map:
-				if rv.Type().Key().Kind() == reflect.String {
-					mv := rv.MapIndex(reflect.ValueOf(seg))
-					if !mv.IsValid() {
-						return nil, false
-					}
-					cur = mv.Interface()
-				} else {
-					return nil, false
-				}
-			case reflect.Struct:
-				f := rv.FieldByName(seg)
-				if f.IsValid() {
-					cur = f.Interface()
-				} else {
-					// try method? (not implemented)
-					return nil, false
-				}
-			default:
-				return nil, false
+		}
+	case reflect.Struct:
+		if idx, ok := e.structField(rv.Type(), seg); ok {
+			f, err := rv.FieldByIndexErr(idx)
+			if err != nil {
+				// nil bir gömülü pointer üzerinden gelen alan
+				return nil, false, nil
 			}
+			return f.Interface(), true, nil
 		}
 	}
-	return cur, true
+	if m, ok := method(cur, seg); ok {
+		v, err := callFunc(seg, m, nil)
+		return v, err == nil, err
+	}
+	return nil, false, nil
 }
 
-func lookupVal(data map[string]interface{}, path string) interface{} {
-	v, _ := lookup(data, path)
-	return v
+// indirect: pointer ve interface'leri değerin kendisine ulaşana kadar çözer; nil bir pointer'da
+// geçersiz bir reflect.Value döner.
+func indirect(rv reflect.Value) reflect.Value {
+	for rv.Kind() == reflect.Ptr || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			return reflect.Value{}
+		}
+		rv = rv.Elem()
+	}
+	return rv
 }
 
-// -------------------- compile (tokens -> AST nodes) --------------------
-
-func compileTokens(tokens []*Token) ([]Node, error) {
-	nodes := []Node{}
-	i := 0
-	for i < len(tokens) {
-		t := tokens[i]
-		switch t.Type {
-		case TText:
-			nodes = append(nodes, &TextNode{Text: t.Value})
-			i++
-		case TVar:
-			// parse filters from t.Raw maybe in future; currently only default supported.
-			filters := []string{}
-			// if user wants filters like <{ var | upper }>, varPattern must be extended.
-			nodes = append(nodes, &VarNode{Name: t.Value, Default: t.Default, Filters: filters})
-			i++
-		case TIf:
-			ifNode, ni, err := parseIf(tokens, i)
-			if err != nil {
-				return nil, err
-			}
-			nodes = append(nodes, ifNode)
-			i = ni
-		case TFor:
-			forNode, ni, err := parseFor(tokens, i)
-			if err != nil {
-				return nil, err
-			}
-			nodes = append(nodes, forNode)
-			i = ni
-		case TSwitch:
-			switchNode, ni, err := parseSwitch(tokens, i)
-			if err != nil {
-				return nil, err
-			}
-			nodes = append(nodes, switchNode)
-			i = ni
-		default:
-			return nil, fmt.Errorf("unexpected token %v at position %d (raw: %s)", t.Type, i, t.Raw)
+// method: v'nin name isimli export edilmiş metodu. Pointer alıcılı metotlar, pointer olmayan
+// bir değer üzerinde de (değerin bir kopyası üzerinden) bulunur.
+func method(v interface{}, name string) (reflect.Value, bool) {
+	rv := reflect.ValueOf(v)
+	if !rv.IsValid() {
+		return reflect.Value{}, false
+	}
+	if m := rv.MethodByName(name); m.IsValid() {
+		return m, true
+	}
+	if rv.Kind() != reflect.Ptr {
+		p := reflect.New(rv.Type())
+		p.Elem().Set(rv)
+		if m := p.MethodByName(name); m.IsValid() {
+			return m, true
 		}
 	}
-	return nodes, nil
+	return reflect.Value{}, false
 }
 
-func parseIf(tokens []*Token, start int) (*IfNode, int, error) {
-	// tokens[start] is TIf
-	root := &IfNode{}
-	branches := []IfBranch{{Expr: tokens[start].Value, Body: []Node{}}}
-	elseBody := []Node{}
-	currentBody := &branches[0].Body
-	depth := 0
-
-	i := start + 1
-	for i < len(tokens) {
-		t := tokens[i]
-		switch t.Type {
-		case TIf:
-			// nested if: append token as part of body by compiling nested structure
-			nested, ni, err := parseIf(tokens, i)
-			if err != nil {
-				return nil, 0, err
-			}
-			*currentBody = append(*currentBody, nested)
-			i = ni
-			continue
-		case TEndIf:
-			if depth == 0 {
-				// finish
-				root.Branches = branches
-				root.Else = elseBody
-				return root, i + 1, nil
-			}
-			depth--
-			*currentBody = append(*currentBody, &TextNode{Text: t.Value})
-		case TElseIf:
-			if depth == 0 {
-				branches = append(branches, IfBranch{Expr: t.Value, Body: []Node{}})
-				currentBody = &branches[len(branches)-1].Body
-				i++
-				continue
-			}
-			*currentBody = append(*currentBody, &TextNode{Text: t.Value})
-		case TElse:
-			if depth == 0 {
-				elseBody = []Node{}
-				currentBody = &elseBody
-				i++
-				continue
-			}
-			*currentBody = append(*currentBody, &TextNode{Text: t.Value})
-		case TFor:
-			fnode, ni, err := parseFor(tokens, i)
-			if err != nil {
-				return nil, 0, err
-			}
-			*currentBody = append(*currentBody, fnode)
-			i = ni
-			continue
-		case TSwitch:
-			snode, ni, err := parseSwitch(tokens, i)
-			if err != nil {
-				return nil, 0, err
-			}
-			*currentBody = append(*currentBody, snode)
-			i = ni
-			continue
-		default:
-			// Text or Var
-			switch t.Type {
-			case TText:
-				*currentBody = append(*currentBody, &TextNode{Text: t.Value})
-			case TVar:
-				*currentBody = append(*currentBody, &VarNode{Name: t.Value, Default: t.Default})
-			default:
-				return nil, 0, fmt.Errorf("unexpected token inside if: %v", t.Type)
+// index: x[k] için değer. Slice, dizi ve string'lerde k tam sayı olmalıdır ve negatifse sondan
+// sayılır; string'ler karakter (rune) bazında indekslenir. Map'lerde k, anahtar türüne çevrilir
+// (int veya başka karşılaştırılabilir anahtarlı map'ler dahil); struct'larda k alan ismidir.
+// Aralık dışındaki indeksler ve bulunamayan anahtarlar ok=false döner.
+func (e *Engine) index(v, k interface{}) (interface{}, bool, error) {
+	rv := indirect(reflect.ValueOf(v))
+	switch rv.Kind() {
+	case reflect.Invalid:
+		return nil, false, nil
+	case reflect.Slice, reflect.Array, reflect.String:
+		i, ok := toInt(k)
+		if !ok {
+			return nil, false, fmt.Errorf("cannot index %T with %v (%T), index must be an integer", v, k, k)
+		}
+		if rv.Kind() == reflect.String {
+			runes := []rune(rv.String())
+			if i = position(i, len(runes)); i < 0 || i >= int64(len(runes)) {
+				return nil, false, nil
 			}
-			i++
+			return string(runes[i]), true, nil
+		}
+		if i = position(i, rv.Len()); i < 0 || i >= int64(rv.Len()) {
+			return nil, false, nil
+		}
+		return rv.Index(int(i)).Interface(), true, nil
+	case reflect.Map:
+		key, err := convertArg(k, rv.Type().Key())
+		if err != nil {
+			return nil, false, fmt.Errorf("invalid key for %T: %v", v, err)
+		}
+		if !key.Comparable() {
+			return nil, false, fmt.Errorf("invalid key for %T: %T is not comparable", v, k)
+		}
+		mv := rv.MapIndex(key)
+		if !mv.IsValid() {
+			return nil, false, nil
+		}
+		return mv.Interface(), true, nil
+	case reflect.Struct:
+		if name, ok := k.(string); ok {
+			return e.field(v, name)
 		}
 	}
-	return nil, 0, fmt.Errorf("unclosed if starting at token %d", start)
+	return nil, false, fmt.Errorf("cannot index %T with %v (%T)", v, k, k)
 }
 
-func parseFor(tokens []*Token, start int) (*ForNode, int, error) {
-	// tokens[start] is TFor with Value like "idx, item:listExpr" or "item:listExpr"
-	parts := strings.SplitN(tokens[start].Value, ":", 2)
-	if len(parts) != 2 {
-		return nil, 0, fmt.Errorf("invalid for tag: %s", tokens[start].Raw)
-	}
-	left := strings.TrimSpace(parts[0])
-	listExpr := strings.TrimSpace(parts[1])
-
-	indexVar := ""
-	itemVar := ""
-	if strings.Contains(left, ",") {
-		p := strings.SplitN(left, ",", 2)
-		indexVar = strings.TrimSpace(p[0])
-		itemVar = strings.TrimSpace(p[1])
-	} else {
-		itemVar = left
+// slice: x[lo:hi] için değer; lo veya hi nil ise baş veya son kullanılır. Negatif sınırlar
+// sondan sayılır, aralık dışındaki sınırlar uzunluğa kırpılır.
+func slice(v, lo, hi interface{}) (interface{}, error) {
+	rv := indirect(reflect.ValueOf(v))
+	var runes []rune
+	n := 0
+	switch rv.Kind() {
+	case reflect.String:
+		runes = []rune(rv.String())
+		n = len(runes)
+	case reflect.Slice, reflect.Array:
+		n = rv.Len()
+	default:
+		return nil, fmt.Errorf("cannot slice %T", v)
 	}
-
-	node := &ForNode{IndexVar: indexVar, ItemVar: itemVar, ListExpr: listExpr, Body: []Node{}}
-	i := start + 1
-	depth := 0
-	for i < len(tokens) {
-		t := tokens[i]
-		switch t.Type {
-		case TFor:
-			// nested for
-			nf, ni, err := parseFor(tokens, i)
-			if err != nil {
-				return nil, 0, err
-			}
-			node.Body = append(node.Body, nf)
-			i = ni
-			continue
-		case TEndFor:
-			if depth == 0 {
-				return node, i + 1, nil
-			}
-			depth--
-			node.Body = append(node.Body, &TextNode{Text: t.Value})
-		case TIf:
-			ifn, ni, err := parseIf(tokens, i)
-			if err != nil {
-				return nil, 0, err
-			}
-			node.Body = append(node.Body, ifn)
-			i = ni
-			continue
-		case TSwitch:
-			sn, ni, err := parseSwitch(tokens, i)
-			if err != nil {
-				return nil, 0, err
-			}
-			node.Body = append(node.Body, sn)
-			i = ni
-			continue
-		default:
-			switch t.Type {
-			case TText:
-				node.Body = append(node.Body, &TextNode{Text: t.Value})
-			case TVar:
-				node.Body = append(node.Body, &VarNode{Name: t.Value, Default: t.Default})
-			default:
-				return nil, 0, fmt.Errorf("unexpected token in for: %v", t.Type)
-			}
-			i++
+	bound := func(b interface{}, def int) (int, error) {
+		if b == nil {
+			return def, nil
+		}
+		i, ok := toInt(b)
+		if !ok {
+			return 0, fmt.Errorf("slice bound must be an integer, got %v (%T)", b, b)
 		}
+		i = position(i, n)
+		if i < 0 {
+			return 0, nil
+		}
+		if i > int64(n) {
+			return n, nil
+		}
+		return int(i), nil
 	}
-	return nil, 0, fmt.Errorf("unclosed for starting at token %d", start)
-}
-
-func parseSwitch(tokens []*Token, start int) (*SwitchNode, int, error) {
-	node := &SwitchNode{Expr: tokens[start].Value, Cases: []SwitchCase{}, Default: []Node{}}
-	i := start + 1
-	depth := 0 // İç içe switch'leri takip etmek için
-	currentCond := ""
-	currentBody := []Node{}
-
-	flushCase := func() {
-		if currentCond != "" {
-			node.Cases = append(node.Cases, SwitchCase{Cond: currentCond, Body: currentBody})
-		} else if len(currentBody) > 0 {
-			// currentCond boşsa ve body varsa, bu default case'dir
-			node.Default = currentBody
+	l, err := bound(lo, 0)
+	if err != nil {
+		return nil, err
+	}
+	h, err := bound(hi, n)
+	if err != nil {
+		return nil, err
+	}
+	if h < l {
+		h = l
+	}
+	switch rv.Kind() {
+	case reflect.String:
+		return string(runes[l:h]), nil
+	case reflect.Array:
+		if !rv.CanAddr() {
+			// interface içindeki diziler adreslenemez; kopyası üzerinden dilimlenir
+			cp := reflect.New(rv.Type()).Elem()
+			cp.Set(rv)
+			rv = cp
 		}
-		currentCond = ""
-		currentBody = []Node{}
 	}
+	return rv.Slice(l, h).Interface(), nil
+}
 
-	for i < len(tokens) {
-		t := tokens[i]
-		switch t.Type {
-		case TSwitch:
-			// İç içe switch
-			depth++
-			nn, ni, err := parseSwitch(tokens, i)
-			if err != nil {
-				return nil, 0, err
-			}
-			currentBody = append(currentBody, nn)
-			i = ni
-			continue
-		case TEndSwitch:
-			if depth == 0 {
-				// Ana switch bitiyor
-				flushCase()
-				return node, i + 1, nil
-			}
-			// İç içe switch bitiyor
-			depth--
-			currentBody = append(currentBody, &TextNode{Text: t.Value})
-			i++
-		case TCase:
-			if depth == 0 {
-				// Ana switch için yeni case
-				flushCase()
-				currentCond = t.Value
-			} else {
-				// İç içe switch için normal token
-				currentBody = append(currentBody, &TextNode{Text: t.Value})
-			}
-			i++
-		case TDefault:
-			if depth == 0 {
-				// Ana switch için default case
-				flushCase()
-				currentCond = "" // Default case için cond boş
-			} else {
-				// İç içe switch için normal token
-				currentBody = append(currentBody, &TextNode{Text: t.Value})
-			}
-			i++
-		case TIf:
-			in, ni, err := parseIf(tokens, i)
-			if err != nil {
-				return nil, 0, err
-			}
-			currentBody = append(currentBody, in)
-			i = ni
-			continue
-		case TFor:
-			fn, ni, err := parseFor(tokens, i)
-			if err != nil {
-				return nil, 0, err
-			}
-			currentBody = append(currentBody, fn)
-			i = ni
-			continue
-		default:
-			switch t.Type {
-			case TText:
-				currentBody = append(currentBody, &TextNode{Text: t.Value})
-			case TVar:
-				currentBody = append(currentBody, &VarNode{Name: t.Value, Default: t.Default})
-			default:
-				return nil, 0, fmt.Errorf("unexpected token in switch: %v", t.Type)
-			}
-			i++
-		}
+// position: negatif bir indeksi n uzunluğundaki bir dizinin sonundan sayar
+func position(i int64, n int) int64 {
+	if i < 0 {
+		return i + int64(n)
 	}
-	return nil, 0, fmt.Errorf("unclosed switch starting at token %d", start)
+	return i
 }
@@ -0,0 +1,866 @@
+package vingo
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// -------------------- Expressions (tokenizer + recursive descent parser) --------------------
+//
+// Koşullar ve switch ifadeleri compile sırasında bir kez ayrıştırılır ve render sırasında
+// ağaç olarak değerlendirilir. Öncelik (düşükten yükseğe):
+//
+//	or ||
+//	and &&
+//	not !
+//	== != > < >= <=
+//	~ (string birleştirme)
+//	+ -
+//	* / %
+//	- (tekli)
+//	.alan, .metot(arg, ...), [indeks], [başlangıç:bitiş]
+//	literal, değişken, fonksiyon(arg, ...), ( ... ), . (switch değeri)
+//
+// "not" ve "!" aynı operatördür ve Python'daki gibi karşılaştırmanın tamamını olumsuzlar:
+// not a == b ve !a == b -> not (a == b).
+//
+// Çıktı etiketlerinde ifadeden sonra "|" ile filtre zinciri gelebilir (bkz. parsePipeline).
+
+type exprTokKind int
+
+const (
+	xEOF exprTokKind = iota
+	xIdent
+	xNumber
+	xString
+	xOp // operatörler ve noktalama: == != >= <= > < && || ! ( ) [ ] : , . | ~ + - * / %
+)
+
+type exprTok struct {
+	kind exprTokKind
+	text string      // kaynaktaki hali
+	val  interface{} // xNumber ve xString için değer
+	off  int         // ifade içindeki byte offset'i
+}
+
+// lexExpr: ifade metnini token'lara ayırır. Tırnak içindeki operatörler string'in parçasıdır.
+func lexExpr(src string) ([]exprTok, error) {
+	var toks []exprTok
+	i := 0
+	for i < len(src) {
+		c := src[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case isIdentStart(c):
+			j := i + 1
+			for j < len(src) && isIdentPart(src[j]) {
+				j++
+			}
+			toks = append(toks, exprTok{kind: xIdent, text: src[i:j], off: i})
+			i = j
+		case c >= '0' && c <= '9':
+			j := i
+			for j < len(src) && src[j] >= '0' && src[j] <= '9' {
+				j++
+			}
+			isFloat := false
+			if j+1 < len(src) && src[j] == '.' && src[j+1] >= '0' && src[j+1] <= '9' {
+				isFloat = true
+				j++
+				for j < len(src) && src[j] >= '0' && src[j] <= '9' {
+					j++
+				}
+			}
+			text := src[i:j]
+			var val interface{}
+			if isFloat {
+				f, err := strconv.ParseFloat(text, 64)
+				if err != nil {
+					return nil, &syntaxError{off: i, msg: fmt.Sprintf("invalid number %s", text)}
+				}
+				val = f
+			} else {
+				n, err := strconv.Atoi(text)
+				if err != nil {
+					return nil, &syntaxError{off: i, msg: fmt.Sprintf("invalid number %s", text)}
+				}
+				val = n
+			}
+			toks = append(toks, exprTok{kind: xNumber, text: text, val: val, off: i})
+			i = j
+		case c == '"' || c == '\'':
+			j := i + 1
+			for j < len(src) && src[j] != c {
+				if src[j] == '\\' {
+					j++
+				}
+				j++
+			}
+			if j >= len(src) {
+				return nil, &syntaxError{off: i, msg: fmt.Sprintf("unterminated string starting at offset %d", i)}
+			}
+			text := src[i : j+1]
+			toks = append(toks, exprTok{kind: xString, text: text, val: unquote(text), off: i})
+			i = j + 1
+		default:
+			op := ""
+			for _, cand := range []string{"==", "!=", ">=", "<=", "&&", "||", ">", "<", "!", "(", ")", "[", "]", ":", ",", ".", "|", "~", "+", "-", "*", "/", "%"} {
+				if strings.HasPrefix(src[i:], cand) {
+					op = cand
+					break
+				}
+			}
+			if op == "" {
+				return nil, &syntaxError{off: i, msg: fmt.Sprintf("unexpected character %q at offset %d", c, i)}
+			}
+			toks = append(toks, exprTok{kind: xOp, text: op, off: i})
+			i += len(op)
+		}
+	}
+	return append(toks, exprTok{kind: xEOF, off: len(src)}), nil
+}
+
+// syntaxError: ifadenin off byte'ındaki bir sözdizimi hatası; derleyici hatayı etiketin içinde
+// bu noktanın sütunuyla raporlar
+type syntaxError struct {
+	off int
+	msg string
+}
+
+func (e *syntaxError) Error() string { return e.msg }
+
+// isKeyword: ifadelerde değişken veya fonksiyon ismi olarak kullanılamayan kelimeler
+func isKeyword(name string) bool {
+	switch name {
+	case "and", "or", "not", "true", "false":
+		return true
+	}
+	return false
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isIdentPart(c byte) bool {
+	return isIdentStart(c) || (c >= '0' && c <= '9')
+}
+
+// unquote: "..." veya '...' literal'ini çözer; geçersiz kaçışlarda tırnakları atıp içeriği döner
+func unquote(text string) string {
+	if text[0] == '\'' {
+		// tek tırnaklı string'leri Go'nun anlayacağı çift tırnaklı hale getir
+		inner := strings.ReplaceAll(text[1:len(text)-1], `\'`, `'`)
+		text = `"` + strings.ReplaceAll(inner, `"`, `\"`) + `"`
+	}
+	if s, err := strconv.Unquote(text); err == nil {
+		return s
+	}
+	return text[1 : len(text)-1]
+}
+
+// exprParser: token listesinden ifade ağacı kuran recursive descent parser
+type exprParser struct {
+	src  string
+	toks []exprTok
+	pos  int
+}
+
+// parseExpr: tek bir ifadeyi ayrıştırır; ifadeden sonra fazladan token kalırsa hata döner
+func parseExpr(src string) (expr, error) {
+	list, err := parseExprList(src)
+	if err != nil {
+		return nil, err
+	}
+	if len(list) != 1 {
+		return nil, fmt.Errorf("expected a single expression in %q", src)
+	}
+	return list[0], nil
+}
+
+// parsePipeline: çıktı etiketlerindeki "ifade | filtre | filtre(arg, ...)" biçimini ayrıştırır.
+// Zincirdeki tırnaklı bir string, eski <{ name | "varsayılan" }> yazımıdır ve default("...") ile aynıdır.
+func parsePipeline(src string) (expr, []Filter, error) {
+	toks, err := lexExpr(src)
+	if err != nil {
+		return nil, nil, err
+	}
+	p := &exprParser{src: src, toks: toks}
+	x, err := p.parseOr()
+	if err != nil {
+		return nil, nil, err
+	}
+	filters := []Filter{}
+	for p.accept("|") {
+		t := p.next()
+		switch {
+		case t.kind == xString:
+			filters = append(filters, Filter{Name: "default", args: []expr{&literalExpr{val: t.val, text: t.text}}})
+			continue
+		case t.kind != xIdent:
+			return nil, nil, p.unexpected(t)
+		}
+		f := Filter{Name: t.text}
+		if p.accept("(") {
+			if f.args, err = p.parseArgs(); err != nil {
+				return nil, nil, err
+			}
+		}
+		filters = append(filters, f)
+	}
+	if t := p.peek(); t.kind != xEOF {
+		return nil, nil, p.unexpected(t)
+	}
+	return x, filters, nil
+}
+
+// parseExprList: virgülle ayrılmış ifadeleri ayrıştırır (switch case listeleri için)
+func parseExprList(src string) ([]expr, error) {
+	toks, err := lexExpr(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &exprParser{src: src, toks: toks}
+	var list []expr
+	for {
+		e, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, e)
+		if !p.accept(",") {
+			break
+		}
+	}
+	if t := p.peek(); t.kind != xEOF {
+		return nil, p.unexpected(t)
+	}
+	return list, nil
+}
+
+func (p *exprParser) peek() exprTok { return p.toks[p.pos] }
+
+func (p *exprParser) next() exprTok {
+	t := p.toks[p.pos]
+	if t.kind != xEOF {
+		p.pos++
+	}
+	return t
+}
+
+// accept: sıradaki token verilen operatör veya anahtar kelimelerden biriyse tüketir
+func (p *exprParser) accept(ops ...string) bool {
+	t := p.peek()
+	if t.kind != xOp && t.kind != xIdent {
+		return false
+	}
+	for _, op := range ops {
+		if t.text == op {
+			p.pos++
+			return true
+		}
+	}
+	return false
+}
+
+func (p *exprParser) unexpected(t exprTok) error {
+	if t.kind == xEOF {
+		return &syntaxError{off: t.off, msg: fmt.Sprintf("unexpected end of expression %q", p.src)}
+	}
+	return &syntaxError{off: t.off, msg: fmt.Sprintf("unexpected %q at offset %d in %q", t.text, t.off, p.src)}
+}
+
+// parseArgs: "(" tüketildikten sonra virgülle ayrılmış argümanları ve kapanış ")" işaretini okur
+func (p *exprParser) parseArgs() ([]expr, error) {
+	var args []expr
+	if p.accept(")") {
+		return args, nil
+	}
+	for {
+		a, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, a)
+		if p.accept(")") {
+			return args, nil
+		}
+		if !p.accept(",") {
+			return nil, p.unexpected(p.peek())
+		}
+	}
+}
+
+func (p *exprParser) parseOr() (expr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept("or", "||") {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &logicalExpr{op: "or", left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *exprParser) parseAnd() (expr, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept("and", "&&") {
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = &logicalExpr{op: "and", left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *exprParser) parseNot() (expr, error) {
+	if p.accept("not") || p.accept("!") {
+		x, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return &notExpr{x: x}, nil
+	}
+	return p.parseComparison()
+}
+
+func (p *exprParser) parseComparison() (expr, error) {
+	left, err := p.parseConcat()
+	if err != nil {
+		return nil, err
+	}
+	t := p.peek()
+	if t.kind == xOp {
+		switch t.text {
+		case "==", "!=", ">", "<", ">=", "<=":
+			p.next()
+			right, err := p.parseConcat()
+			if err != nil {
+				return nil, err
+			}
+			return &compareExpr{op: t.text, left: left, right: right}, nil
+		}
+	}
+	return left, nil
+}
+
+func (p *exprParser) parseConcat() (expr, error) {
+	left, err := p.parseAdditive()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept("~") {
+		right, err := p.parseAdditive()
+		if err != nil {
+			return nil, err
+		}
+		left = &concatExpr{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *exprParser) parseAdditive() (expr, error) {
+	left, err := p.parseMultiplicative()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		t := p.peek()
+		if !p.accept("+", "-") {
+			return left, nil
+		}
+		right, err := p.parseMultiplicative()
+		if err != nil {
+			return nil, err
+		}
+		left = &arithExpr{op: t.text, left: left, right: right}
+	}
+}
+
+func (p *exprParser) parseMultiplicative() (expr, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		t := p.peek()
+		if !p.accept("*", "/", "%") {
+			return left, nil
+		}
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = &arithExpr{op: t.text, left: left, right: right}
+	}
+}
+
+func (p *exprParser) parseUnary() (expr, error) {
+	if p.accept("-") {
+		x, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &negExpr{x: x}, nil
+	}
+	return p.parsePostfix()
+}
+
+// parsePostfix: bir operand ve arkasından gelen .alan erişimleri, .metot(arg, ...) çağrıları,
+// [indeks] ve [başlangıç:bitiş] ifadeleri
+func (p *exprParser) parsePostfix() (expr, error) {
+	x, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		if p.accept("[") {
+			if x, err = p.parseIndex(x); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if !p.accept(".") {
+			return x, nil
+		}
+		name := p.next()
+		if name.kind != xIdent {
+			return nil, p.unexpected(name)
+		}
+		if p.accept("(") {
+			args, err := p.parseArgs()
+			if err != nil {
+				return nil, err
+			}
+			x = &methodExpr{x: x, name: name.text, args: args}
+			continue
+		}
+		x = &fieldExpr{x: x, name: name.text}
+	}
+}
+
+// parseIndex: "[" tüketildikten sonra x[i] veya x[lo:hi] (lo ve hi opsiyonel) ifadesini okur
+func (p *exprParser) parseIndex(x expr) (expr, error) {
+	var lo, hi expr
+	var err error
+	if t := p.peek(); t.kind != xOp || t.text != ":" {
+		if lo, err = p.parseOr(); err != nil {
+			return nil, err
+		}
+	}
+	if !p.accept(":") {
+		if lo == nil || !p.accept("]") {
+			return nil, p.unexpected(p.peek())
+		}
+		return &indexExpr{x: x, index: lo}, nil
+	}
+	if t := p.peek(); t.kind != xOp || t.text != "]" {
+		if hi, err = p.parseOr(); err != nil {
+			return nil, err
+		}
+	}
+	if !p.accept("]") {
+		return nil, p.unexpected(p.peek())
+	}
+	return &sliceExpr{x: x, lo: lo, hi: hi}, nil
+}
+
+func (p *exprParser) parsePrimary() (expr, error) {
+	t := p.next()
+	switch t.kind {
+	case xNumber, xString:
+		return &literalExpr{val: t.val, text: t.text}, nil
+	case xIdent:
+		switch t.text {
+		case "true":
+			return &literalExpr{val: true, text: t.text}, nil
+		case "false":
+			return &literalExpr{val: false, text: t.text}, nil
+		}
+		if isKeyword(t.text) {
+			return nil, p.unexpected(t)
+		}
+		if p.accept("(") {
+			args, err := p.parseArgs()
+			if err != nil {
+				return nil, err
+			}
+			return &callExpr{name: t.text, args: args}, nil
+		}
+		return &identExpr{name: t.text}, nil
+	case xOp:
+		switch t.text {
+		case "(":
+			x, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			if !p.accept(")") {
+				return nil, &syntaxError{off: t.off, msg: fmt.Sprintf("missing ) in %q", p.src)}
+			}
+			return x, nil
+		case ".":
+			return &dotExpr{}, nil
+		}
+	}
+	return nil, p.unexpected(t)
+}
+
+// -------------------- Expression tree --------------------
+
+// expr: derlenmiş bir ifade
+type expr interface {
+	eval(s *state, data map[string]interface{}) (interface{}, error)
+	String() string
+}
+
+// undefined: bulunamayan bir değişken (StrictVariables kapalıyken). Koşullarda false, çıktıda boş
+// değerdir; sadece karşılaştırmalarda eski davranışla yolun kendisi gibi bir string olarak ele
+// alınır (role == admin).
+type undefined string
+
+type literalExpr struct {
+	val  interface{}
+	text string
+}
+
+func (e *literalExpr) eval(s *state, data map[string]interface{}) (interface{}, error) {
+	return e.val, nil
+}
+
+func (e *literalExpr) String() string { return e.text }
+
+type identExpr struct {
+	name string
+}
+
+func (e *identExpr) eval(s *state, data map[string]interface{}) (interface{}, error) {
+	if v, ok := data[e.name]; ok {
+		return v, nil
+	}
+	return s.undefined(e)
+}
+
+func (e *identExpr) String() string { return e.name }
+
+type fieldExpr struct {
+	x    expr
+	name string
+}
+
+func (e *fieldExpr) eval(s *state, data map[string]interface{}) (interface{}, error) {
+	v, err := e.x.eval(s, data)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := v.(undefined); ok {
+		return s.undefined(e)
+	}
+	fv, ok, err := s.eng.field(v, e.name)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return s.undefined(e)
+	}
+	return fv, nil
+}
+
+func (e *fieldExpr) String() string { return e.x.String() + "." + e.name }
+
+// indexExpr: x[index]. Slice, dizi ve string'lerde (karakter bazında) negatif indeksler sondan
+// sayılır; map'lerde index, map'in anahtar türüne çevrilir.
+type indexExpr struct {
+	x, index expr
+}
+
+func (e *indexExpr) eval(s *state, data map[string]interface{}) (interface{}, error) {
+	v, err := e.x.eval(s, data)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := v.(undefined); ok {
+		return s.undefined(e)
+	}
+	k, err := e.index.eval(s, data)
+	if err != nil {
+		return nil, err
+	}
+	iv, ok, err := s.eng.index(v, k)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return s.undefined(e)
+	}
+	return iv, nil
+}
+
+func (e *indexExpr) String() string { return e.x.String() + "[" + e.index.String() + "]" }
+
+// sliceExpr: x[lo:hi], Go'daki gibi; lo ve hi opsiyoneldir ve negatifse sondan sayılır
+type sliceExpr struct {
+	x      expr
+	lo, hi expr // nil olabilir
+}
+
+func (e *sliceExpr) eval(s *state, data map[string]interface{}) (interface{}, error) {
+	v, err := e.x.eval(s, data)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := v.(undefined); ok {
+		return s.undefined(e)
+	}
+	var bounds [2]interface{}
+	for i, b := range []expr{e.lo, e.hi} {
+		if b == nil {
+			continue
+		}
+		if bounds[i], err = b.eval(s, data); err != nil {
+			return nil, err
+		}
+	}
+	return slice(v, bounds[0], bounds[1])
+}
+
+func (e *sliceExpr) String() string {
+	str := func(x expr) string {
+		if x == nil {
+			return ""
+		}
+		return x.String()
+	}
+	return e.x.String() + "[" + str(e.lo) + ":" + str(e.hi) + "]"
+}
+
+// dotExpr: switch case'lerinde "." switch edilen değeri gösterir: <{ case . > 5 }>
+type dotExpr struct{}
+
+func (e *dotExpr) eval(s *state, data map[string]interface{}) (interface{}, error) {
+	return s.dot, nil
+}
+
+func (e *dotExpr) String() string { return "." }
+
+type notExpr struct {
+	x expr
+}
+
+func (e *notExpr) eval(s *state, data map[string]interface{}) (interface{}, error) {
+	v, err := e.x.eval(s, data)
+	if err != nil {
+		return nil, err
+	}
+	return !condTruthy(v), nil
+}
+
+func (e *notExpr) String() string { return "not " + e.x.String() }
+
+// logicalExpr: and / or, kısa devre ile
+type logicalExpr struct {
+	op          string
+	left, right expr
+}
+
+func (e *logicalExpr) eval(s *state, data map[string]interface{}) (interface{}, error) {
+	l, err := e.left.eval(s, data)
+	if err != nil {
+		return nil, err
+	}
+	if lt := condTruthy(l); (e.op == "and" && !lt) || (e.op == "or" && lt) {
+		return lt, nil
+	}
+	r, err := e.right.eval(s, data)
+	if err != nil {
+		return nil, err
+	}
+	return condTruthy(r), nil
+}
+
+func (e *logicalExpr) String() string {
+	return "(" + e.left.String() + " " + e.op + " " + e.right.String() + ")"
+}
+
+type compareExpr struct {
+	op          string
+	left, right expr
+}
+
+func (e *compareExpr) eval(s *state, data map[string]interface{}) (interface{}, error) {
+	l, err := e.left.eval(s, data)
+	if err != nil {
+		return nil, err
+	}
+	r, err := e.right.eval(s, data)
+	if err != nil {
+		return nil, err
+	}
+	return compareValues(l, r, e.op)
+}
+
+func (e *compareExpr) String() string {
+	return e.left.String() + " " + e.op + " " + e.right.String()
+}
+
+// arithExpr: + - * / %
+type arithExpr struct {
+	op          string
+	left, right expr
+}
+
+func (e *arithExpr) eval(s *state, data map[string]interface{}) (interface{}, error) {
+	l, err := e.left.eval(s, data)
+	if err != nil {
+		return nil, err
+	}
+	r, err := e.right.eval(s, data)
+	if err != nil {
+		return nil, err
+	}
+	if err := operands(l, r); err != nil {
+		return nil, err
+	}
+	return arith(e.op, l, r)
+}
+
+// operands: aritmetikte bulunamayan bir değişken strict mod olmasa da hatadır; sonucu
+// sessizce 0 yapmak yerine değişkenin ismi raporlanır
+func operands(vs ...interface{}) error {
+	for _, v := range vs {
+		if u, ok := v.(undefined); ok {
+			return &undefinedError{name: string(u)}
+		}
+	}
+	return nil
+}
+
+func (e *arithExpr) String() string {
+	return "(" + e.left.String() + " " + e.op + " " + e.right.String() + ")"
+}
+
+type negExpr struct {
+	x expr
+}
+
+func (e *negExpr) eval(s *state, data map[string]interface{}) (interface{}, error) {
+	v, err := e.x.eval(s, data)
+	if err != nil {
+		return nil, err
+	}
+	if err := operands(v); err != nil {
+		return nil, err
+	}
+	return arith("-", 0, v)
+}
+
+func (e *negExpr) String() string { return "-" + e.x.String() }
+
+// concatExpr: ~ iki değeri metin olarak birleştirir
+type concatExpr struct {
+	left, right expr
+}
+
+func (e *concatExpr) eval(s *state, data map[string]interface{}) (interface{}, error) {
+	l, err := e.left.eval(s, data)
+	if err != nil {
+		return nil, err
+	}
+	r, err := e.right.eval(s, data)
+	if err != nil {
+		return nil, err
+	}
+	return toString(l) + toString(r), nil
+}
+
+func (e *concatExpr) String() string { return e.left.String() + " ~ " + e.right.String() }
+
+// walkExpr: ifade ağacındaki her düğüm için (önce kendisi, sonra alt ifadeleri) fn'i çağırır
+func walkExpr(x expr, fn func(expr)) {
+	fn(x)
+	switch e := x.(type) {
+	case *fieldExpr:
+		walkExpr(e.x, fn)
+	case *notExpr:
+		walkExpr(e.x, fn)
+	case *negExpr:
+		walkExpr(e.x, fn)
+	case *logicalExpr:
+		walkExpr(e.left, fn)
+		walkExpr(e.right, fn)
+	case *compareExpr:
+		walkExpr(e.left, fn)
+		walkExpr(e.right, fn)
+	case *arithExpr:
+		walkExpr(e.left, fn)
+		walkExpr(e.right, fn)
+	case *concatExpr:
+		walkExpr(e.left, fn)
+		walkExpr(e.right, fn)
+	case *callExpr:
+		for _, a := range e.args {
+			walkExpr(a, fn)
+		}
+	case *methodExpr:
+		walkExpr(e.x, fn)
+		for _, a := range e.args {
+			walkExpr(a, fn)
+		}
+	case *indexExpr:
+		walkExpr(e.x, fn)
+		walkExpr(e.index, fn)
+	case *sliceExpr:
+		walkExpr(e.x, fn)
+		if e.lo != nil {
+			walkExpr(e.lo, fn)
+		}
+		if e.hi != nil {
+			walkExpr(e.hi, fn)
+		}
+	}
+}
+
+// usesDot: ifade switch değerine (.) başvuruyor mu
+func usesDot(x expr) bool {
+	found := false
+	walkExpr(x, func(e expr) {
+		if _, ok := e.(*dotExpr); ok {
+			found = true
+		}
+	})
+	return found
+}
+
+// undefinedError: StrictVariables açıkken (veya aritmetikte) bulunamayan bir değişken
+type undefinedError struct {
+	name string
+}
+
+func (e *undefinedError) Error() string { return fmt.Sprintf("undefined variable %q", e.name) }
+
+// undefined: bulunamayan bir değişken için strict modda hata, aksi halde undefined değeri döner
+func (s *state) undefined(e expr) (interface{}, error) {
+	if s.eng.strict {
+		return nil, &undefinedError{name: e.String()}
+	}
+	return undefined(e.String()), nil
+}
+
+// evalCondition: derlenmiş bir koşulu değerlendirip doğruluk değerini döner
+func evalCondition(s *state, cond expr, data map[string]interface{}) (bool, error) {
+	v, err := cond.eval(s, data)
+	if err != nil {
+		return false, err
+	}
+	return condTruthy(v), nil
+}
@@ -0,0 +1,164 @@
+package vingo
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseExprPrecedence(t *testing.T) {
+	tests := []struct {
+		src  string
+		want string
+	}{
+		{`1 + 2 * 3`, `(1 + (2 * 3))`},
+		{`(1 + 2) * 3`, `((1 + 2) * 3)`},
+		{`a - b - c`, `((a - b) - c)`},
+		{`10 / 2 % 3`, `((10 / 2) % 3)`},
+		{`-a * b`, `(-a * b)`},
+		{`a or b and c`, `(a or (b and c))`},
+		{`(a or b) and c`, `((a or b) and c)`},
+		{`a || b && c`, `(a or (b and c))`},
+		{`a >= 1 and a <= 3`, `(a >= 1 and a <= 3)`},
+		{`a ~ b + c`, `a ~ (b + c)`},
+		{`not a and b`, `(not a and b)`},
+		{`!(a or b)`, `not (a or b)`},
+		{`!a == b`, `not a == b`},
+		{`!a and b`, `(not a and b)`},
+		{`a == "x == y"`, `a == "x == y"`},
+		{`s == "a and b" or x`, `(s == "a and b" or x)`},
+		{`a ~ "|" ~ b`, `a ~ "|" ~ b`},
+		{`user.tags[0] == 'a, b'`, `user.tags[0] == 'a, b'`},
+	}
+	for _, tt := range tests {
+		x, err := parseExpr(tt.src)
+		if err != nil {
+			t.Errorf("parseExpr(%q): %v", tt.src, err)
+			continue
+		}
+		if got := x.String(); got != tt.want {
+			t.Errorf("parseExpr(%q) = %s, want %s", tt.src, got, tt.want)
+		}
+	}
+}
+
+func TestParseExprErrors(t *testing.T) {
+	tests := []struct {
+		src  string
+		want string
+	}{
+		{`a ==`, "unexpected end of expression"},
+		{`1 +`, "unexpected end of expression"},
+		{`not`, "unexpected end of expression"},
+		{`(a`, "missing )"},
+		{`a b`, `unexpected "b"`},
+		{`"abc`, "unterminated string"},
+		{`a == "x" y`, `unexpected "y"`},
+	}
+	for _, tt := range tests {
+		_, err := parseExpr(tt.src)
+		if err == nil {
+			t.Errorf("parseExpr(%q) succeeded, want error containing %q", tt.src, tt.want)
+			continue
+		}
+		if !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("parseExpr(%q) error = %q, want it to contain %q", tt.src, err, tt.want)
+		}
+	}
+}
+
+func TestExprEval(t *testing.T) {
+	data := map[string]interface{}{
+		"a":     "x",
+		"b":     "x",
+		"t":     true,
+		"f":     false,
+		"n":     7,
+		"s":     "x == y",
+		"price": 2.5,
+		"qty":   4,
+		"user":  map[string]interface{}{"first": "Ada", "last": "Lovelace"},
+	}
+	tests := []struct {
+		src  string
+		want string
+	}{
+		// öncelik
+		{`<{ 1 + 2 * 3 }>`, `7`},
+		{`<{ (1 + 2) * 3 }>`, `9`},
+		{`<{ 10 - 4 - 3 }>`, `3`},
+		{`<{ -n + 10 }>`, `3`},
+		{`<{ 7 / 2 }> <{ 7.0 / 2 }> <{ n % 4 }>`, `3 3.5 3`},
+		{`<{ price * qty }>`, `10`},
+		{`<{ if t or f and f }>y<{ else }>n<{ /if }>`, `y`},
+		{`<{ if (t or f) and f }>y<{ else }>n<{ /if }>`, `n`},
+		{`<{ if n > 5 and n < 10 }>y<{ else }>n<{ /if }>`, `y`},
+		{`<{ if n % 2 == 1 }>odd<{ /if }>`, `odd`},
+
+		// not ve ! aynı önceliktedir ve karşılaştırmanın tamamını olumsuzlar
+		{`<{ if not a == b }>y<{ else }>n<{ /if }>`, `n`},
+		{`<{ if !a == b }>y<{ else }>n<{ /if }>`, `n`},
+		{`<{ if not f == t }>y<{ else }>n<{ /if }>`, `y`},
+		{`<{ if !f == t }>y<{ else }>n<{ /if }>`, `y`},
+		{`<{ if not a == "y" }>y<{ else }>n<{ /if }>`, `y`},
+		{`<{ if !a == "y" }>y<{ else }>n<{ /if }>`, `y`},
+		{`<{ if ! t or t }>y<{ else }>n<{ /if }>`, `y`},
+		{`<{ if not t and f }>y<{ else }>n<{ /if }>`, `n`},
+		{`<{ if !(t and f) }>y<{ else }>n<{ /if }>`, `y`},
+		{`<{ if not not t }>y<{ else }>n<{ /if }>`, `y`},
+
+		// tırnak içindeki operatörler string'in parçasıdır
+		{`<{ if s == "x == y" }>y<{ else }>n<{ /if }>`, `y`},
+		{`<{ if s != 'x == y' and a == "x" }>y<{ else }>n<{ /if }>`, `n`},
+		{`<{ if "a or b" == "a" }>y<{ else }>n<{ /if }>`, `n`},
+		{`<{ "a | b" }>`, `a | b`},
+		{`<{ "1 + 2" ~ "=" ~ 1 + 2 }>`, `1 + 2=3`},
+
+		// bulunamayan değişkenler koşulda false, karşılaştırmada eski davranışla kelimedir
+		{`<{ if missing }>y<{ else }>n<{ /if }>`, `n`},
+		{`<{ if user.banned }>banned<{ else }>ok<{ /if }>`, `ok`},
+		{`<{ if not user.banned }>ok<{ else }>banned<{ /if }>`, `ok`},
+		{`<{ if !missing and t }>y<{ else }>n<{ /if }>`, `y`},
+		{`<{ if a == x }>y<{ else }>n<{ /if }>`, `y`},
+
+		// birleştirme
+		{`<{ user.first ~ " " ~ user.last | upper }>`, `ADA LOVELACE`},
+		{`<{ user.first + "!" }>`, `Ada!`},
+		{`<{ n ~ "%" }>`, `7%`},
+		{`<{ missing ~ "!" }>`, `!`},
+		{`<{ price * missing | default(0) }>`, `0`},
+	}
+	e := New(Options{})
+	for _, tt := range tests {
+		if got := renderString(t, e, "t.txt", tt.src, data); got != tt.want {
+			t.Errorf("%s = %q, want %q", tt.src, got, tt.want)
+		}
+	}
+}
+
+func TestExprEvalErrors(t *testing.T) {
+	tests := []struct {
+		src  string
+		want string
+	}{
+		{`<{ price * qty }>`, `undefined variable "qty"`},
+		{`<{ -qty }>`, `undefined variable "qty"`},
+		{`<{ 1 / 0 }>`, "division by zero"},
+		{`<{ "a" * 2 }>`, "mismatched types string and int"},
+	}
+	e := New(Options{})
+	for _, tt := range tests {
+		tpl, err := e.Compile("t.txt", tt.src)
+		if err != nil {
+			t.Fatalf("Compile(%q): %v", tt.src, err)
+		}
+		var b strings.Builder
+		err = tpl.Execute(&b, map[string]interface{}{"price": 2})
+		if err == nil {
+			t.Errorf("%s rendered %q, want an error", tt.src, b.String())
+			continue
+		}
+		if !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s error = %q, want it to contain %q", tt.src, err, tt.want)
+		}
+	}
+}
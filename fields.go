@@ -0,0 +1,90 @@
+package vingo
+
+import (
+	"reflect"
+	"strings"
+)
+
+// -------------------- Struct fields --------------------
+
+// structFields: bir struct türünün template'ten erişilebilen alanları, isimden alan indeksine.
+// Her tür için bir kez hesaplanıp engine'de saklanır.
+type structFields struct {
+	names map[string][]int
+	fold  map[string][]int // küçük harfe çevrilmiş isimler; sadece CaseInsensitiveFields açıkken
+}
+
+// structField: t türünde name ile erişilen alanın indeksi. Sırasıyla vingo etiketindeki isme,
+// (JSONTags açıksa) json etiketindeki isme, Go'daki alan ismine ve (CaseInsensitiveFields açıksa)
+// büyük/küçük harf farkı gözetmeden bunlardan birine bakılır.
+func (e *Engine) structField(t reflect.Type, name string) ([]int, bool) {
+	var sf *structFields
+	if v, ok := e.fields.Load(t); ok {
+		sf = v.(*structFields)
+	} else {
+		v, _ := e.fields.LoadOrStore(t, e.buildFields(t))
+		sf = v.(*structFields)
+	}
+	if idx, ok := sf.names[name]; ok {
+		return idx, true
+	}
+	if sf.fold != nil {
+		idx, ok := sf.fold[strings.ToLower(name)]
+		return idx, ok
+	}
+	return nil, false
+}
+
+// buildFields: t'nin görünür alanlarından isim tablosunu kurar. Export edilmemiş alanlar ve
+// "-" etiketli alanlar atlanır. Aynı isim birden fazla alana denk gelirse Go'daki gibi daha az
+// gömülü olan kazanır; etiketle verilen isimler Go isimlerinden önce gelir.
+func (e *Engine) buildFields(t reflect.Type) *structFields {
+	sf := &structFields{names: map[string][]int{}}
+	add := func(m map[string][]int, name string, idx []int) {
+		if cur, ok := m[name]; !ok || len(idx) < len(cur) {
+			m[name] = idx
+		}
+	}
+	var plain []reflect.StructField
+	for _, f := range reflect.VisibleFields(t) {
+		if !f.IsExported() {
+			continue
+		}
+		tag, ok := tagName(f.Tag.Get("vingo"))
+		if !ok && e.jsonTags {
+			tag, ok = tagName(f.Tag.Get("json"))
+		}
+		if tag == "-" {
+			continue
+		}
+		if ok && tag != "" {
+			add(sf.names, tag, f.Index)
+		}
+		plain = append(plain, f)
+	}
+	for _, f := range plain {
+		if _, taken := sf.names[f.Name]; !taken {
+			sf.names[f.Name] = f.Index
+		}
+	}
+	if e.caseInsensitive {
+		sf.fold = make(map[string][]int, len(sf.names))
+		for name, idx := range sf.names {
+			add(sf.fold, strings.ToLower(name), idx)
+		}
+	}
+	return sf
+}
+
+// tagName: `vingo:"name,opts"` veya `json:"name,omitempty"` etiketindeki isim.
+// Etiket hiç yoksa ok false döner; "-" alanın gizlendiğini gösterir.
+func tagName(tag string) (name string, ok bool) {
+	if tag == "" {
+		return "", false
+	}
+	if tag == "-" {
+		return "-", true
+	}
+	name, _, _ = strings.Cut(tag, ",")
+	return name, true
+}
@@ -0,0 +1,160 @@
+package vingo
+
+import (
+	"fmt"
+	"html"
+	"reflect"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// -------------------- Filters --------------------
+
+// FilterFunc: bir filtre. in filtrelenen değerdir (değişken bulunamadıysa nil), args etiketteki
+// argümanlardır. Dönen hata render'ı durdurur ve bir *ExecError içinde raporlanır.
+type FilterFunc func(in interface{}, args ...interface{}) (interface{}, error)
+
+// Filter: bir çıktı etiketindeki tek filtre çağrısı, örneğin truncate(20)
+type Filter struct {
+	Name string
+
+	args []expr // her render'da değerlendirilen argüman ifadeleri
+}
+
+// builtinFilters: her yeni Engine'e kopyalanan varsayılan filtreler
+var builtinFilters = map[string]FilterFunc{
+	"upper":  stringFilter(strings.ToUpper),
+	"lower":  stringFilter(strings.ToLower),
+	"trim":   stringFilter(strings.TrimSpace),
+	"title":  stringFilter(titleCase),
+	"escape": escapeFilter,
+	// write the value as-is in every template and context
+	"raw":      trustFilter[escaped],
+	"noescape": trustFilter[escaped],
+	// mark the value as trusted HTML: as-is in HTML text, escaped elsewhere
+	"safe":     trustFilter[HTML],
+	"safejs":   trustFilter[JS],
+	"safeurl":  trustFilter[URL],
+	"safecss":  trustFilter[CSS],
+	"default":  defaultFilter,
+	"truncate": truncateFilter,
+	"length":   lengthFilter,
+	"join":     joinFilter,
+	"replace":  replaceFilter,
+}
+
+// toString: filtre ve çıktılar için bir değerin metin hali; nil (ve nil pointer) boş string olur
+func toString(v interface{}) string {
+	switch t := v.(type) {
+	case nil, undefined:
+		return ""
+	case string:
+		return t
+	}
+	if rv := reflect.ValueOf(v); rv.Kind() == reflect.Ptr && rv.IsNil() {
+		return ""
+	}
+	return fmt.Sprintf("%v", v)
+}
+
+// stringFilter: argümansız bir string fonksiyonunu filtreye çevirir
+func stringFilter(fn func(string) string) FilterFunc {
+	return func(in interface{}, args ...interface{}) (interface{}, error) {
+		if len(args) != 0 {
+			return nil, fmt.Errorf("takes no arguments")
+		}
+		return fn(toString(in)), nil
+	}
+}
+
+// escapeFilter: değeri HTML için kaçışlar; sonuç güvenilir HTML olduğundan ikinci kez kaçışlanmaz
+func escapeFilter(in interface{}, args ...interface{}) (interface{}, error) {
+	if len(args) != 0 {
+		return nil, fmt.Errorf("takes no arguments")
+	}
+	return HTML(html.EscapeString(toString(in))), nil
+}
+
+func titleCase(s string) string {
+	prev := ' '
+	return strings.Map(func(r rune) rune {
+		if unicode.IsSpace(prev) {
+			prev = r
+			return unicode.ToTitle(r)
+		}
+		prev = r
+		return r
+	}, s)
+}
+
+// defaultFilter: default("x") — değer yoksa (nil) veya boş string ise argümanı döner
+func defaultFilter(in interface{}, args ...interface{}) (interface{}, error) {
+	if len(args) != 1 {
+		return nil, fmt.Errorf("expects 1 argument, got %d", len(args))
+	}
+	if in == nil || in == "" {
+		return args[0], nil
+	}
+	return in, nil
+}
+
+// truncateFilter: truncate(n) veya truncate(n, "…") — n karakterden uzun metni keser
+func truncateFilter(in interface{}, args ...interface{}) (interface{}, error) {
+	if len(args) < 1 || len(args) > 2 {
+		return nil, fmt.Errorf("expects 1 or 2 arguments, got %d", len(args))
+	}
+	n, ok := args[0].(int)
+	if !ok || n < 0 {
+		return nil, fmt.Errorf("length must be a non-negative integer, got %v", args[0])
+	}
+	suffix := "..."
+	if len(args) == 2 {
+		suffix = toString(args[1])
+	}
+	s := toString(in)
+	if utf8.RuneCountInString(s) <= n {
+		return s, nil
+	}
+	return string([]rune(s)[:n]) + suffix, nil
+}
+
+// lengthFilter: string'in karakter, slice/map'in eleman sayısı
+func lengthFilter(in interface{}, args ...interface{}) (interface{}, error) {
+	if s, ok := in.(string); ok {
+		return utf8.RuneCountInString(s), nil
+	}
+	rv := reflect.ValueOf(in)
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array, reflect.Map, reflect.Chan:
+		return rv.Len(), nil
+	case reflect.Invalid:
+		return 0, nil
+	}
+	return nil, fmt.Errorf("cannot take length of %T", in)
+}
+
+// joinFilter: join(", ") — slice elemanlarını ayraçla birleştirir
+func joinFilter(in interface{}, args ...interface{}) (interface{}, error) {
+	sep := ""
+	if len(args) > 0 {
+		sep = toString(args[0])
+	}
+	rv := reflect.ValueOf(in)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return toString(in), nil
+	}
+	parts := make([]string, rv.Len())
+	for i := range parts {
+		parts[i] = toString(rv.Index(i).Interface())
+	}
+	return strings.Join(parts, sep), nil
+}
+
+// replaceFilter: replace("eski", "yeni")
+func replaceFilter(in interface{}, args ...interface{}) (interface{}, error) {
+	if len(args) != 2 {
+		return nil, fmt.Errorf("expects 2 arguments, got %d", len(args))
+	}
+	return strings.ReplaceAll(toString(in), toString(args[0]), toString(args[1])), nil
+}
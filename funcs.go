@@ -0,0 +1,186 @@
+package vingo
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// -------------------- Functions --------------------
+
+// errorType: (T, error) dönen fonksiyonların ikinci sonucunu tanımak için
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
+// builtinFuncs: her yeni Engine'e eklenen varsayılan fonksiyonlar
+var builtinFuncs = map[string]interface{}{
+	"range": rangeFunc,
+}
+
+// checkFunc: fn'in template'ten çağrılabilecek bir fonksiyon olup olmadığını kontrol eder
+func checkFunc(name string, fn interface{}) (reflect.Value, error) {
+	v := reflect.ValueOf(fn)
+	if v.Kind() != reflect.Func || v.IsNil() {
+		return reflect.Value{}, fmt.Errorf("value for function %q is %T, not a function", name, fn)
+	}
+	if !callable(v.Type()) {
+		return reflect.Value{}, fmt.Errorf("function %q must return one value, or a value and an error", name)
+	}
+	return v, nil
+}
+
+// callable: fonksiyon türü tek sonuç veya ikincisi error olan iki sonuç dönüyor mu
+func callable(t reflect.Type) bool {
+	return t.NumOut() == 1 || (t.NumOut() == 2 && t.Out(1) == errorType)
+}
+
+// callExpr: <{ name(arg, ...) }>, Engine.Funcs ile eklenmiş bir fonksiyonun çağrısı
+type callExpr struct {
+	name string
+	args []expr
+}
+
+func (e *callExpr) eval(s *state, data map[string]interface{}) (interface{}, error) {
+	fn, ok := s.eng.function(e.name)
+	if !ok {
+		return nil, fmt.Errorf("unknown function %q", e.name)
+	}
+	args, err := evalArgs(s, e.args, data)
+	if err != nil {
+		return nil, err
+	}
+	return callFunc(e.name, fn, args)
+}
+
+func (e *callExpr) String() string {
+	args := make([]string, len(e.args))
+	for i, a := range e.args {
+		args[i] = a.String()
+	}
+	return e.name + "(" + strings.Join(args, ", ") + ")"
+}
+
+// evalArgs: argüman ifadelerini değerlendirir; bulunamayan değişkenler nil olur
+func evalArgs(s *state, list []expr, data map[string]interface{}) ([]interface{}, error) {
+	args := make([]interface{}, len(list))
+	for i, a := range list {
+		v, err := a.eval(s, data)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := v.(undefined); ok {
+			v = nil
+		}
+		args[i] = v
+	}
+	return args, nil
+}
+
+// callFunc: fn'i (bir fonksiyon veya metot) args ile çağırır. Argümanlar parametre türlerine
+// çevrilir; fonksiyonun döndüğü hata ve fonksiyon içindeki panic'ler render hatası olarak döner.
+func callFunc(name string, fn reflect.Value, args []interface{}) (result interface{}, err error) {
+	t := fn.Type()
+	if !callable(t) {
+		return nil, fmt.Errorf("%s must return one value, or a value and an error, to be called from a template", name)
+	}
+	fixed := t.NumIn()
+	if t.IsVariadic() {
+		fixed--
+		if len(args) < fixed {
+			return nil, fmt.Errorf("%s: want at least %d arguments, got %d", name, fixed, len(args))
+		}
+	} else if len(args) != fixed {
+		return nil, fmt.Errorf("%s: want %d arguments, got %d", name, fixed, len(args))
+	}
+
+	in := make([]reflect.Value, len(args))
+	for i, a := range args {
+		var pt reflect.Type
+		if i < fixed {
+			pt = t.In(i)
+		} else {
+			pt = t.In(fixed).Elem()
+		}
+		v, err := convertArg(a, pt)
+		if err != nil {
+			return nil, fmt.Errorf("%s: argument %d: %v", name, i+1, err)
+		}
+		in[i] = v
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			result, err = nil, fmt.Errorf("error calling %s: %v", name, r)
+		}
+	}()
+	out := fn.Call(in)
+	if len(out) == 2 && !out[1].IsNil() {
+		return nil, fmt.Errorf("error calling %s: %w", name, out[1].Interface().(error))
+	}
+	return out[0].Interface(), nil
+}
+
+// convertArg: bir template değerini t türünde bir argümana çevirir. Atanabilir değerler olduğu
+// gibi geçer; sayılar kendi aralarında ve string türleri kendi aralarında çevrilir. nil (veya
+// bulunamayan değişken) t'nin sıfır değeri olur.
+func convertArg(a interface{}, t reflect.Type) (reflect.Value, error) {
+	if a == nil {
+		return reflect.Zero(t), nil
+	}
+	v := reflect.ValueOf(a)
+	if v.Type().AssignableTo(t) {
+		return v, nil
+	}
+	switch {
+	case isNumberKind(v.Kind()) && isNumberKind(t.Kind()):
+		if isFloatKind(v.Kind()) && !isFloatKind(t.Kind()) && v.Float() != float64(int64(v.Float())) {
+			return reflect.Value{}, fmt.Errorf("cannot use %v as %s without losing precision", a, t)
+		}
+		return v.Convert(t), nil
+	case v.Kind() == reflect.String && t.Kind() == reflect.String,
+		v.Kind() == t.Kind() && v.Type().ConvertibleTo(t):
+		return v.Convert(t), nil
+	}
+	return reflect.Value{}, fmt.Errorf("cannot use %v (%T) as %s", a, a, t)
+}
+
+func isNumberKind(k reflect.Kind) bool {
+	return (k >= reflect.Int && k <= reflect.Uint64) || isFloatKind(k)
+}
+
+func isFloatKind(k reflect.Kind) bool {
+	return k == reflect.Float32 || k == reflect.Float64
+}
+
+// methodExpr: <{ user.HasPerm("admin") }>, bir değerin metodunun argümanlarla çağrısı
+type methodExpr struct {
+	x    expr
+	name string
+	args []expr
+}
+
+func (e *methodExpr) eval(s *state, data map[string]interface{}) (interface{}, error) {
+	v, err := e.x.eval(s, data)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := v.(undefined); ok {
+		return s.undefined(e.x)
+	}
+	m, ok := method(v, e.name)
+	if !ok {
+		return nil, fmt.Errorf("%s (%T) has no method %s", e.x, v, e.name)
+	}
+	args, err := evalArgs(s, e.args, data)
+	if err != nil {
+		return nil, err
+	}
+	return callFunc(e.name, m, args)
+}
+
+func (e *methodExpr) String() string {
+	args := make([]string, len(e.args))
+	for i, a := range e.args {
+		args[i] = a.String()
+	}
+	return e.x.String() + "." + e.name + "(" + strings.Join(args, ", ") + ")"
+}
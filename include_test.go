@@ -0,0 +1,135 @@
+package vingo
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+var includeFS = fstest.MapFS{
+	"secret.txt":                 {Data: []byte(`secret`)},
+	"views/page.html":            {Data: []byte(`[<{ include "partials/card.html" }>]`)},
+	"views/with.html":            {Data: []byte(`<{ include "partials/user.html" with user }>`)},
+	"views/withmap.html":         {Data: []byte(`<{ include "partials/user.html" with extra }>`)},
+	"views/only.html":            {Data: []byte(`<{ include "partials/scope.html" only }>`)},
+	"views/scope.html":           {Data: []byte(`<{ include "partials/scope.html" }>`)},
+	"views/dynamic.html":         {Data: []byte(`<{ include name }>`)},
+	"views/partials/card.html":   {Data: []byte(`card <{ include "../footer.html" }>`)},
+	"views/partials/user.html":   {Data: []byte(`<{ user.Name }>`)},
+	"views/partials/scope.html":  {Data: []byte(`<{ title }>|<{ site }>`)},
+	"views/partials/nested.html": {Data: []byte(`nested`)},
+	"views/footer.html":          {Data: []byte(`footer`)},
+}
+
+func TestInclude(t *testing.T) {
+	data := map[string]interface{}{
+		"user":  map[string]interface{}{"Name": "Ada"},
+		"extra": map[string]interface{}{"user": map[string]interface{}{"Name": "Bob"}},
+		"title": "Home",
+	}
+	tests := []struct {
+		name string
+		file string
+		vars map[string]interface{}
+		want string
+	}{
+		{"static relative", "page.html", nil, `[card footer]`},
+		{"with value", "with.html", nil, `Ada`},
+		{"with map", "withmap.html", nil, `Bob`},
+		{"shares data", "scope.html", nil, `Home|site`},
+		{"only", "only.html", nil, `|site`},
+		{"dynamic", "dynamic.html", map[string]interface{}{"name": "partials/nested.html"}, `nested`},
+		{"dynamic from root", "dynamic.html", map[string]interface{}{"name": "/footer.html"}, `footer`},
+	}
+	e := New(Options{FS: includeFS, Root: "views", Globals: map[string]interface{}{"site": "site"}})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := shallowCopyMap(data)
+			for k, v := range tt.vars {
+				m[k] = v
+			}
+			got, err := e.Render(tt.file, m)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIncludeConfinement(t *testing.T) {
+	dir := t.TempDir()
+	root := filepath.Join(dir, "views")
+	for name, src := range map[string]string{
+		"secret.txt":           "secret",
+		"views2/x.html":        "sibling",
+		"views/dynamic.html":   `<{ include name }>`,
+		"views/partials/a.txt": "a",
+	} {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	engines := map[string]*Engine{
+		"os": New(Options{Root: root}),
+		"fs": New(Options{FS: includeFS, Root: "views"}),
+	}
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"../secret.txt", `must not contain ".."`},
+		{"partials/../../secret.txt", `must not contain ".."`},
+		{"/../secret.txt", `must not contain ".."`},
+		{"../views2/x.html", `must not contain ".."`},
+		{"", "does not name a template"},
+	}
+	for kind, e := range engines {
+		for _, tt := range tests {
+			_, err := e.Render("dynamic.html", map[string]interface{}{"name": tt.name})
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("%s: include %q: error = %v, want it to contain %q", kind, tt.name, err, tt.want)
+			}
+		}
+	}
+	got, err := engines["os"].Render("dynamic.html", map[string]interface{}{"name": "partials/a.txt"})
+	if err != nil || got != "a" {
+		t.Errorf("os: include partials/a.txt = %q, %v", got, err)
+	}
+}
+
+func TestWithin(t *testing.T) {
+	dir := t.TempDir()
+	root := filepath.Join(dir, "views")
+	tests := []struct {
+		e    *Engine
+		name string
+		want bool
+	}{
+		{New(Options{FS: includeFS, Root: "views"}), "views/a.html", true},
+		{New(Options{FS: includeFS, Root: "views"}), "views/p/a.html", true},
+		{New(Options{FS: includeFS, Root: "views"}), "secret.txt", false},
+		{New(Options{FS: includeFS, Root: "views"}), "views2/a.html", false},
+		{New(Options{FS: includeFS, Root: "views"}), "views/../secret.txt", false},
+		{New(Options{FS: includeFS}), "secret.txt", true},
+		{New(Options{FS: includeFS}), "../secret.txt", false},
+		{New(Options{Root: root}), filepath.Join(root, "a.html"), true},
+		{New(Options{Root: root}), filepath.Join(root, "p", "a.html"), true},
+		{New(Options{Root: root}), filepath.Join(dir, "secret.txt"), false},
+		{New(Options{Root: root}), root + "2" + string(filepath.Separator) + "a.html", false},
+		{New(Options{}), filepath.Join(dir, "secret.txt"), true},
+	}
+	for _, tt := range tests {
+		if got := tt.e.within(tt.name); got != tt.want {
+			t.Errorf("within(%q) with root %q = %v, want %v", tt.name, tt.e.root, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,220 @@
+package vingo
+
+import (
+	"fmt"
+	"iter"
+	"reflect"
+	"sort"
+)
+
+// -------------------- Loop sources --------------------
+
+// Loop: for döngüsünün gövdesinde "loop" ismiyle görünen döngü bilgisi
+type Loop struct {
+	Index    int   // 0'dan başlayan sıra
+	Index1   int   // 1'den başlayan sıra
+	RevIndex int   // bu elemandan sonra kalan eleman sayısı; uzunluk bilinmiyorsa -1
+	First    bool  // ilk eleman mı
+	Last     bool  // son eleman mı; iterator ve channel'larda sadece gövde loop.Last'ı okuyorsa bilinir
+	Length   int   // eleman sayısı; iterator ve channel'larda -1
+	Even     bool  // Index1 çift mi (2., 4., ... satırlar)
+	Odd      bool  // Index1 tek mi (1., 3., ... satırlar)
+	Parent   *Loop // dıştaki döngünün bilgisi; en dıştaki döngüde nil
+}
+
+func newLoop(i, length int, last bool, parent *Loop) *Loop {
+	l := &Loop{
+		Index:    i,
+		Index1:   i + 1,
+		RevIndex: -1,
+		First:    i == 0,
+		Last:     last,
+		Length:   length,
+		Even:     (i+1)%2 == 0,
+		Odd:      (i+1)%2 == 1,
+		Parent:   parent,
+	}
+	if length >= 0 {
+		l.RevIndex = length - i - 1
+	}
+	return l
+}
+
+// Cycle: değerler arasında sırayla döner: <{ loop.Cycle("odd", "even") }> ilk elemanda "odd",
+// ikincide "even", üçüncüde yine "odd" verir.
+func (l *Loop) Cycle(values ...interface{}) interface{} {
+	if len(values) == 0 {
+		return nil
+	}
+	return values[l.Index%len(values)]
+}
+
+// sequence: bir for döngüsünün kaynağı üzerinde sırayla (anahtar, değer) ikilileri üreten yineleyici.
+// Slice, dizi, sayı ve channel'larda anahtar sıra numarasıdır; map ve iter.Seq2'de gerçek anahtardır.
+type sequence struct {
+	next   func() (key, val interface{}, ok bool)
+	stop   func()
+	length int   // eleman sayısı; iterator ve channel'larda bilinmediği için -1
+	keyed  bool  // tek değişkenli formda değişkene anahtar bağlanır (map ve iter.Seq2, Go'daki gibi)
+	err    error // iterator panic ile bittiyse hatası; next false döndükten sonra okunur
+}
+
+// iterate: v'yi gezen bir sequence döner. Desteklenenler: slice ve diziler, map'ler (anahtarlar
+// sıralı), tam sayılar (0..n-1), iter.Seq ve iter.Seq2 fonksiyonları ve okunabilir channel'lar.
+// nil map, channel, fonksiyon ve pointer'lar boş sayılır.
+func iterate(v interface{}) (*sequence, error) {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Map, reflect.Chan, reflect.Func, reflect.Ptr, reflect.Interface, reflect.Slice:
+		if rv.IsNil() {
+			return indexed(0, nil), nil
+		}
+	}
+	rv = indirect(rv)
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		return indexed(rv.Len(), func(i int) (interface{}, interface{}) {
+			return i, rv.Index(i).Interface()
+		}), nil
+	case reflect.Map:
+		keys := rv.MapKeys()
+		sort.Slice(keys, func(i, j int) bool { return lessKey(keys[i], keys[j]) })
+		sq := indexed(len(keys), func(i int) (interface{}, interface{}) {
+			return keys[i].Interface(), rv.MapIndex(keys[i]).Interface()
+		})
+		sq.keyed = true
+		return sq, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, _ := toInt(rv.Interface())
+		if n < 0 {
+			n = 0
+		}
+		return indexed(int(n), func(i int) (interface{}, interface{}) { return i, i }), nil
+	case reflect.Chan:
+		if rv.Type().ChanDir()&reflect.RecvDir == 0 {
+			return nil, fmt.Errorf("cannot range over send-only channel %T", v)
+		}
+		i := 0
+		return &sequence{
+			next: func() (interface{}, interface{}, bool) {
+				x, ok := rv.Recv()
+				if !ok {
+					return nil, nil, false
+				}
+				i++
+				return i - 1, x.Interface(), true
+			},
+			stop:   func() {},
+			length: -1,
+		}, nil
+	case reflect.Func:
+		if pairs, ok := isSeq(rv.Type()); ok {
+			sq := &sequence{length: -1, keyed: pairs}
+			sq.next, sq.stop = iter.Pull2(seqFunc(rv, &sq.err))
+			return sq, nil
+		}
+	}
+	return nil, fmt.Errorf("cannot range over %T", v)
+}
+
+// indexed: n elemanlı, i. (anahtar, değer) ikilisi at(i) ile okunan bir sequence
+func indexed(n int, at func(i int) (interface{}, interface{})) *sequence {
+	i := 0
+	return &sequence{
+		next: func() (interface{}, interface{}, bool) {
+			if i >= n {
+				return nil, nil, false
+			}
+			i++
+			k, v := at(i - 1)
+			return k, v, true
+		},
+		stop:   func() {},
+		length: n,
+	}
+}
+
+// isSeq: t, iter.Seq (func(yield func(V) bool)) veya iter.Seq2 (func(yield func(K, V) bool))
+// biçiminde bir fonksiyon mu; pairs Seq2 için true olur.
+func isSeq(t reflect.Type) (pairs, ok bool) {
+	if t.NumIn() != 1 || t.NumOut() != 0 {
+		return false, false
+	}
+	y := t.In(0)
+	if y.Kind() != reflect.Func || y.NumOut() != 1 || y.Out(0).Kind() != reflect.Bool || (y.NumIn() != 1 && y.NumIn() != 2) {
+		return false, false
+	}
+	return y.NumIn() == 2, true
+}
+
+// seqFunc: herhangi bir türdeki iter.Seq veya iter.Seq2 fonksiyonunu iter.Seq2[any, any]'e
+// çevirir; tek değerli Seq'lerde anahtar sıra numarasıdır. Iterator içindeki panic'ler, callFunc'taki
+// gibi yakalanıp *errp'ye yazılır ve yineleme orada biter.
+func seqFunc(fn reflect.Value, errp *error) iter.Seq2[interface{}, interface{}] {
+	return func(yield func(k, v interface{}) bool) {
+		defer func() {
+			if r := recover(); r != nil {
+				*errp = fmt.Errorf("error in iterator: %v", r)
+			}
+		}()
+		i := 0
+		y := reflect.MakeFunc(fn.Type().In(0), func(args []reflect.Value) []reflect.Value {
+			var k, v interface{}
+			if len(args) == 1 {
+				k, v = i, args[0].Interface()
+			} else {
+				k, v = args[0].Interface(), args[1].Interface()
+			}
+			i++
+			return []reflect.Value{reflect.ValueOf(yield(k, v))}
+		})
+		fn.Call([]reflect.Value{y})
+	}
+}
+
+// lessKey: map anahtarlarının sıralaması; sayılar ve string'ler doğal sırayla, diğerleri
+// metin halleriyle karşılaştırılır, böylece map'ler her render'da aynı sırayla gezilir.
+func lessKey(a, b reflect.Value) bool {
+	if a.Kind() == reflect.Interface {
+		a, b = a.Elem(), b.Elem()
+	}
+	if a.Kind() == b.Kind() {
+		switch a.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			return a.Int() < b.Int()
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+			return a.Uint() < b.Uint()
+		case reflect.Float32, reflect.Float64:
+			return a.Float() < b.Float()
+		case reflect.String:
+			return a.String() < b.String()
+		case reflect.Bool:
+			return !a.Bool() && b.Bool()
+		}
+	}
+	return fmt.Sprint(a) < fmt.Sprint(b)
+}
+
+// rangeFunc: range(son), range(baş, son) veya range(baş, son, adım); son dahil değildir
+func rangeFunc(args ...int) ([]int, error) {
+	start, end, step := 0, 0, 1
+	switch len(args) {
+	case 1:
+		end = args[0]
+	case 2:
+		start, end = args[0], args[1]
+	case 3:
+		start, end, step = args[0], args[1], args[2]
+	default:
+		return nil, fmt.Errorf("want 1 to 3 arguments, got %d", len(args))
+	}
+	if step == 0 {
+		return nil, fmt.Errorf("step cannot be zero")
+	}
+	var out []int
+	for i := start; (step > 0 && i < end) || (step < 0 && i > end); i += step {
+		out = append(out, i)
+	}
+	return out, nil
+}
@@ -0,0 +1,177 @@
+package vingo
+
+import (
+	"iter"
+	"maps"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestForSources(t *testing.T) {
+	ch := func(vs ...int) <-chan int {
+		c := make(chan int, len(vs))
+		for _, v := range vs {
+			c <- v
+		}
+		close(c)
+		return c
+	}
+	var nilMap map[string]int
+	var nilChan chan int
+	var nilSeq iter.Seq[int]
+	var nilPtr *[]int
+	arr := [3]string{"a", "b", "c"}
+	tests := []struct {
+		name string
+		src  string
+		xs   interface{}
+		want string
+	}{
+		{"slice", `<{ for x in xs }><{ x }>,<{ /for }>`, []string{"a", "b"}, `a,b,`},
+		{"slice index", `<{ for i, x in xs }><{ i }>=<{ x }>,<{ /for }>`, []string{"a", "b"}, `0=a,1=b,`},
+		{"array pointer", `<{ for x in xs }><{ x }><{ /for }>`, &arr, `abc`},
+		{"map keys sorted", `<{ for k in xs }><{ k }>,<{ /for }>`, map[string]int{"b": 2, "a": 1, "c": 3}, `a,b,c,`},
+		{"map key value", `<{ for k, v in xs }><{ k }>=<{ v }>,<{ /for }>`, map[int]string{10: "x", 2: "y"}, `2=y,10=x,`},
+		{"integer", `<{ for i in xs }><{ i }><{ /for }>`, 4, `0123`},
+		{"negative integer", `<{ for i in xs }><{ i }><{ else }>none<{ /for }>`, -2, `none`},
+		{"range", `<{ for i in range(1, 7, 2) }><{ i }><{ /for }>`, nil, `135`},
+		{"seq", `<{ for x in xs }><{ x }><{ /for }>`, slices.Values([]int{7, 8}), `78`},
+		{"seq2", `<{ for k, v in xs }><{ k }>:<{ v }> <{ /for }>`, maps.All(map[string]int{"a": 1}), `a:1 `},
+		{"seq2 single var", `<{ for k in xs }><{ k }><{ /for }>`, slices.All([]string{"x", "y"}), `01`},
+		{"channel", `<{ for x in xs }><{ x }><{ /for }>`, ch(1, 2, 3), `123`},
+		{"empty slice", `<{ for x in xs }><{ x }><{ else }>empty<{ /for }>`, []int{}, `empty`},
+		{"empty channel", `<{ for x in xs }><{ x }><{ else }>empty<{ /for }>`, ch(), `empty`},
+		{"nil map", `<{ for x in xs }><{ x }><{ else }>empty<{ /for }>`, nilMap, `empty`},
+		{"nil chan", `<{ for x in xs }><{ x }><{ else }>empty<{ /for }>`, nilChan, `empty`},
+		{"nil seq", `<{ for x in xs }><{ x }><{ else }>empty<{ /for }>`, nilSeq, `empty`},
+		{"nil pointer", `<{ for x in xs }><{ x }><{ else }>empty<{ /for }>`, nilPtr, `empty`},
+		{"missing", `<{ for x in nothing }><{ x }><{ else }>empty<{ /for }>`, nil, `empty`},
+	}
+	e := New(Options{})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := renderString(t, e, "t.txt", tt.src, map[string]interface{}{"xs": tt.xs})
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestForSourceErrors(t *testing.T) {
+	send := make(chan<- int)
+	panics := func(yield func(int) bool) {
+		yield(1)
+		panic("boom")
+	}
+	tests := []struct {
+		name string
+		xs   interface{}
+		want string
+	}{
+		{"string", "abc", "cannot range over string"},
+		{"send-only channel", send, "cannot range over send-only channel"},
+		{"func", func() {}, "cannot range over func()"},
+		{"iterator panic", iter.Seq[int](panics), "error in iterator: boom"},
+	}
+	e := New(Options{})
+	tpl, err := e.Compile("t.txt", `<{ for x in xs }><{ x }><{ /for }>`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range tests {
+		err := tpl.Execute(&strings.Builder{}, map[string]interface{}{"xs": tt.xs})
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: error = %v, want it to contain %q", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestLoopVariables(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		xs   interface{}
+		want string
+	}{
+		{"index", `<{ for x in xs }><{ loop.Index }><{ loop.Index1 }><{ loop.RevIndex }> <{ /for }>`, []int{5, 6}, `011 120 `},
+		{"first last", `<{ for x in xs }><{ if loop.First }>[<{ /if }><{ x }><{ if loop.Last }>]<{ else }>,<{ /if }><{ /for }>`, []int{1, 2, 3}, `[1,2,3]`},
+		{"length", `<{ for x in xs }><{ loop.Length }><{ /for }>`, []int{1, 2}, `22`},
+		{"odd even", `<{ for x in xs }><{ if loop.Odd }>o<{ /if }><{ if loop.Even }>e<{ /if }><{ /for }>`, 3, `oeo`},
+		{"cycle", `<{ for x in xs }><{ loop.Cycle("a", "b") }><{ /for }>`, 3, `aba`},
+		{"parent", `<{ for x in xs }><{ for y in xs }><{ loop.Parent.Index }><{ loop.Index }> <{ /for }><{ /for }>`, 2, `00 01 10 11 `},
+		{"seq last", `<{ for x in xs }><{ x }><{ if not loop.Last }>,<{ /if }><{ /for }>`, slices.Values([]int{1, 2, 3}), `1,2,3`},
+		{"seq length", `<{ for x in xs }><{ loop.Length }><{ loop.RevIndex }> <{ /for }>`, slices.Values([]int{1, 2}), `-1-1 -1-1 `},
+		{"seq parent last", `<{ for x in xs }><{ for y in 1 }><{ loop.Parent.Last }><{ /for }><{ /for }>`, slices.Values([]int{1, 2}), `falsetrue`},
+		{"seq without last", `<{ for x in xs }><{ x }><{ /for }>`, slices.Values([]int{1, 2}), `12`},
+	}
+	e := New(Options{})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := renderString(t, e, "t.txt", tt.src, map[string]interface{}{"xs": tt.xs})
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestForChannelNoReadAhead: loop.Last okunmuyorsa channel'dan bir sonraki eleman, o ana
+// kadarki çıktı yazılmadan ve break'ten sonra alınmaz
+func TestForChannelNoReadAhead(t *testing.T) {
+	e := New(Options{})
+	tpl, err := e.Compile("t.txt", `<{ for v in ch }><{ v }><{ break if v == 2 }><{ /for }>`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ch := make(chan int, 5)
+	for i := 1; i <= 5; i++ {
+		ch <- i
+	}
+	close(ch)
+	var b strings.Builder
+	if err := tpl.Execute(&b, map[string]interface{}{"ch": ch}); err != nil {
+		t.Fatal(err)
+	}
+	if b.String() != "12" {
+		t.Errorf("got %q, want %q", b.String(), "12")
+	}
+	var rest []int
+	for v := range ch {
+		rest = append(rest, v)
+	}
+	if !slices.Equal(rest, []int{3, 4, 5}) {
+		t.Errorf("channel left with %v, want [3 4 5]", rest)
+	}
+
+	// her eleman bir sonraki gönderilmeden yazılır
+	unbuffered := make(chan int)
+	w := &signalWriter{wrote: make(chan string)}
+	tpl, err = e.Compile("t.txt", `<{ for v in ch }><{ v }><{ /for }>`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan error)
+	go func() { done <- tpl.Execute(w, map[string]interface{}{"ch": unbuffered}) }()
+	for i := 1; i <= 3; i++ {
+		unbuffered <- i
+		if got := <-w.wrote; got != string(rune('0'+i)) {
+			t.Fatalf("wrote %q after sending %d", got, i)
+		}
+	}
+	close(unbuffered)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+}
+
+// signalWriter: her Write'ı bir channel'a bildirir
+type signalWriter struct {
+	wrote chan string
+}
+
+func (w *signalWriter) Write(p []byte) (int, error) {
+	w.wrote <- string(p)
+	return len(p), nil
+}
@@ -1,167 +1,481 @@
 package vingo
 
 import (
+	"errors"
 	"fmt"
-	"html"
-	"reflect"
+	"io"
+	"path/filepath"
 	"strings"
 )
 
+// Node: derlenmiş template ağacının bir parçası. Eval çıktısını doğrudan w'ya yazar,
+// böylece iç içe node'lar ara string'ler üretmeden akış halinde render edilir.
 type Node interface {
-	Eval(data map[string]interface{}) string
+	Eval(s *state, w io.Writer, data map[string]interface{}) error
+	// Position: node'un template kaynağındaki yeri
+	Position() Pos
 }
 
 type TextNode struct {
+	Pos
 	Text string
 }
 
-func (n *TextNode) Eval(data map[string]interface{}) string {
-	return n.Text
+func (n *TextNode) Eval(s *state, w io.Writer, data map[string]interface{}) error {
+	_, err := io.WriteString(w, n.Text)
+	return err
 }
 
+// VarNode: <{ name | filtre | filtre(arg) }>. Filtreler sırayla, değişkenin kendi değeri
+// üzerinde uygulanır; sonuç en son metne çevrilip (gerekirse) kaçışlanır.
 type VarNode struct {
-	Name    string
-	Default string
-	Filters []string
+	Pos
+	Name    string // etiketin ifade metni (filtreler dahil)
+	Filters []Filter
+
+	value  expr
+	ctx    *escContext // etiketin bulunduğu HTML bağlamı; HTML dışı template'lerde ve auto-escape kapalıyken nil
+	escape Escaper
 }
 
-func containsFilter(filters []string, name string) bool {
+func containsFilter(filters []Filter, name string) bool {
 	for _, f := range filters {
-		if f == name {
+		if f.Name == name {
 			return true
 		}
 	}
 	return false
 }
 
-func (n *VarNode) Eval(data map[string]interface{}) string {
-	val, ok := lookup(data, n.Name)
-	var out string
-	if ok {
-		out = fmt.Sprintf("%v", val)
-	} else if n.Default != "" {
-		out = n.Default
-	} else {
-		out = ""
+func (n *VarNode) Eval(s *state, w io.Writer, data map[string]interface{}) error {
+	val, err := evalPipeline(s, n.value, n.Filters, data)
+	if err != nil {
+		return s.wrap(n.Pos, n.Name, err)
 	}
-	// Apply filters in order
-	for _, f := range n.Filters {
-		out = applyFilter(f, out)
+	out := toString(val)
+
+	// Escape for the tag's context (or the template's output format) unless auto-escape is
+	// disabled on the engine or the value is already escaped (raw, captured output); trusted
+	// values (HTML, JS, URL, CSS) are written as-is in their own HTML context
+	if _, done := val.(escaped); n.escape != nil && !done {
+		if out, err = n.escape(trusted(val)); err != nil {
+			return s.wrap(n.Pos, n.Name, err)
+		}
 	}
+	_, err = io.WriteString(w, out)
+	return err
+}
 
-	// Auto-escape unless explicitly marked raw/safe, or global AutoEscape disabled
-	if AutoEscape {
-		if !containsFilter(n.Filters, "raw") && !containsFilter(n.Filters, "safe") && !containsFilter(n.Filters, "noescape") {
-			out = html.EscapeString(out)
+// evalPipeline: ifadeyi değerlendirip sonucu filtrelerden sırayla geçirir. Bulunamayan değişkenler
+// filtrelere nil olarak verilir; default filtresi (veya eski <{ name | "..." }> yazımı) değişkeni
+// strict modda da opsiyonel yapar.
+func evalPipeline(s *state, value expr, filters []Filter, data map[string]interface{}) (interface{}, error) {
+	val, err := value.eval(s, data)
+	if err != nil {
+		var ue *undefinedError
+		if !errors.As(err, &ue) || !containsFilter(filters, "default") {
+			return nil, err
+		}
+		val = nil
+	}
+	if _, ok := val.(undefined); ok {
+		val = nil
+	}
+	// Apply filters in order; names were checked at compile time
+	for _, f := range filters {
+		fn, ok := s.eng.filter(f.Name)
+		if !ok {
+			return nil, fmt.Errorf("unknown filter %q", f.Name)
+		}
+		args, err := evalArgs(s, f.args, data)
+		if err != nil {
+			return nil, err
 		}
+		v, err := fn(val, args...)
+		if err != nil {
+			return nil, fmt.Errorf("filter %s: %v", f.Name, err)
+		}
+		val = v
 	}
-	return out
+	return val, nil
 }
 
 type IfNode struct {
+	Pos
 	Branches []IfBranch
 	Else     []Node
 }
 
 type IfBranch struct {
+	Pos
 	Expr string
 	Body []Node
+
+	cond expr // compile sırasında ayrıştırılmış Expr
 }
 
-func (n *IfNode) Eval(data map[string]interface{}) string {
+func (n *IfNode) Eval(s *state, w io.Writer, data map[string]interface{}) error {
 	for _, b := range n.Branches {
-		ok, err := evalCondition(b.Expr, data)
-		if err == nil && ok {
-			return evalNodes(b.Body, data)
+		ok, err := evalCondition(s, b.cond, data)
+		if err != nil {
+			return s.wrap(b.Pos, b.Expr, err)
+		}
+		if ok {
+			return evalNodes(s, w, b.Body, data)
 		}
 	}
 	// else
-	return evalNodes(n.Else, data)
+	return evalNodes(s, w, n.Else, data)
 }
 
+// ForNode: <{ for item in expr }> veya <{ for key, item in expr }>. Kaynak slice, dizi, map
+// (anahtar sırasıyla), tam sayı, iter.Seq/iter.Seq2 veya channel olabilir (bkz. iterate).
+// İki değişkenli formda ilk değişken sıra numarası, map ve Seq2'de ise anahtardır.
+// Kaynak boşsa veya yoksa <{ else }> sonrasındaki Else gövdesi render edilir.
 type ForNode struct {
-	IndexVar string // optional, can be ""
+	Pos
+	IndexVar string // optional, can be ""; index or key
 	ItemVar  string
 	ListExpr string
 	Body     []Node
+	Else     []Node
+
+	list expr
+	last bool // gövde loop.Last'ı okuyor; uzunluğu bilinmeyen kaynaklar bir eleman ileriden okunur
 }
 
-func (n *ForNode) Eval(data map[string]interface{}) string {
-	seq, ok := lookup(data, n.ListExpr)
-	if !ok {
-		return ""
+func (n *ForNode) Eval(s *state, w io.Writer, data map[string]interface{}) error {
+	src, err := n.list.eval(s, data)
+	if err != nil {
+		return s.wrap(n.Pos, n.ListExpr, err)
+	}
+	if _, ok := src.(undefined); ok || src == nil {
+		return evalNodes(s, w, n.Else, data)
 	}
-	v := reflect.ValueOf(seq)
-	kind := v.Kind()
-	if kind != reflect.Slice && kind != reflect.Array {
-		return ""
+	seq, err := iterate(src)
+	if err != nil {
+		return s.wrap(n.Pos, n.ListExpr, err)
 	}
-	length := v.Len()
-	out := &strings.Builder{}
-	for i := 0; i < length; i++ {
-		item := v.Index(i).Interface()
+	defer seq.stop()
+
+	key, item, ok := seq.next()
+	if !ok {
+		if seq.err != nil {
+			return s.wrap(n.Pos, n.ListExpr, seq.err)
+		}
+		return evalNodes(s, w, n.Else, data)
+	}
+	parent, _ := data["loop"].(*Loop)
+	// iterator ve channel'larda uzunluk bilinmez; Last'ı bilmek için bir eleman ileriden okumak
+	// break'te bir elemanı boşa tüketir ve çıktıyı bir eleman geciktirir, bu yüzden sadece
+	// gövde loop.Last'ı okuyorsa yapılır
+	readAhead := seq.length < 0 && n.last
+	for i := 0; ok; i++ {
+		var nextKey, nextItem interface{}
+		last := i == seq.length-1
+		if readAhead {
+			var more bool
+			nextKey, nextItem, more = seq.next()
+			last = !more
+		}
 		newData := shallowCopyMap(data)
-		if n.IndexVar != "" {
-			newData[n.IndexVar] = i
+		switch {
+		case n.IndexVar != "":
+			newData[n.IndexVar] = key
+			newData[n.ItemVar] = item
+		case seq.keyed:
+			newData[n.ItemVar] = key
+		default:
+			newData[n.ItemVar] = item
+		}
+		newData["loop"] = newLoop(i, seq.length, last, parent)
+		if err := evalNodes(s, w, n.Body, newData); err == errBreak {
+			break
+		} else if err != nil && err != errContinue {
+			return err
 		}
-		newData[n.ItemVar] = item
-		// loop meta
-		loopMeta := map[string]interface{}{
-			"Index":  i,
-			"First":  i == 0,
-			"Last":   i == length-1,
-			"Length": length,
+		if readAhead {
+			key, item, ok = nextKey, nextItem, !last
+		} else {
+			key, item, ok = seq.next()
 		}
-		newData["loop"] = loopMeta
-		out.WriteString(evalNodes(n.Body, newData))
 	}
-	return out.String()
+	if seq.err != nil {
+		return s.wrap(n.Pos, n.ListExpr, seq.err)
+	}
+	return nil
 }
 
+// errBreak, errContinue: LoopControlNode'un en yakın ForNode'a kadar iç içe node'lar (if,
+// switch, block) üzerinden taşınan sinyalleri. Compile, bunların döngü dışında kullanılmasını engeller.
+var (
+	errBreak    = errors.New("break outside of a loop")
+	errContinue = errors.New("continue outside of a loop")
+)
+
+// LoopControlNode: <{ break }> / <{ continue }>, opsiyonel olarak koşullu: <{ break if expr }>
+type LoopControlNode struct {
+	Pos
+	Break bool   // false ise continue
+	Cond  string // opsiyonel koşul
+
+	cond expr
+}
+
+func (n *LoopControlNode) Eval(s *state, w io.Writer, data map[string]interface{}) error {
+	if n.cond != nil {
+		ok, err := evalCondition(s, n.cond, data)
+		if err != nil {
+			return s.wrap(n.Pos, n.Cond, err)
+		}
+		if !ok {
+			return nil
+		}
+	}
+	if n.Break {
+		return errBreak
+	}
+	return errContinue
+}
+
+// SwitchNode: <{ switch expr }>. Her case virgülle ayrılmış ifadelerden oluşur; ifade "."
+// (switch değeri) içeriyorsa koşul olarak değerlendirilir (<{ case . > 5 }>), aksi halde değeri
+// switch değeriyle karşılaştırılır (<{ case "admin", "owner" }>).
 type SwitchNode struct {
+	Pos
 	Expr    string
 	Cases   []SwitchCase
 	Default []Node
+
+	value expr
 }
 
 type SwitchCase struct {
+	Pos
 	Cond string
 	Body []Node
+
+	conds []expr
 }
 
-func (n *SwitchNode) Eval(data map[string]interface{}) string {
-	val := lookupVal(data, n.Expr)
-	// Try to match with case expressions: we evaluate each case as condition:
+func (n *SwitchNode) Eval(s *state, w io.Writer, data map[string]interface{}) error {
+	val, err := n.value.eval(s, data)
+	if err != nil {
+		return s.wrap(n.Pos, n.Expr, err)
+	}
 	for _, c := range n.Cases {
-		// if case expression is a simple literal equal to val -> match
-		// Alternatively evaluate case as condition using evalCondition, but allow bare literal too.
-		ok, err := evalConditionWithValue(c.Cond, val, data)
-		if err == nil && ok {
-			return evalNodes(c.Body, data)
+		ok, err := n.match(s, c, val, data)
+		if err != nil {
+			return s.wrap(c.Pos, c.Cond, err)
+		}
+		if ok {
+			return evalNodes(s, w, c.Body, data)
 		}
 	}
 	// default
-	return evalNodes(n.Default, data)
-}
-
-// -------------------- Filters --------------------
-
-func applyFilter(name string, input string) string {
-	switch name {
-	case "upper":
-		return strings.ToUpper(input)
-	case "lower":
-		return strings.ToLower(input)
-	case "escape":
-		return html.EscapeString(input)
-	case "raw":
-		// explicit raw: return as-is
-		return input
-	case "safe":
-		// alias for raw
-		return input
-	default:
-		// unknown filter: passthrough
-		return input
+	return evalNodes(s, w, n.Default, data)
+}
+
+// match: case listesindeki ifadelerden biri switch değerine uyuyor mu
+func (n *SwitchNode) match(s *state, c SwitchCase, val interface{}, data map[string]interface{}) (bool, error) {
+	prev := s.dot
+	s.dot = val
+	defer func() { s.dot = prev }()
+
+	for _, cond := range c.conds {
+		if usesDot(cond) {
+			ok, err := evalCondition(s, cond, data)
+			if err != nil || ok {
+				return ok, err
+			}
+			continue
+		}
+		cv, err := cond.eval(s, data)
+		if err != nil {
+			return false, err
+		}
+		if ok, err := compareValues(val, cv, "=="); err != nil || ok {
+			return ok, err
+		}
+	}
+	return false, nil
+}
+
+// BlockNode: <{ block name }> ... <{ /block }>. Alt template'ler aynı isimli bir blokla
+// gövdeyi değiştirebilir; extends zinciri compile sırasında çözülür ve override en alttaki
+// (en çok türetilmiş) tanımı gösterir.
+type BlockNode struct {
+	Pos
+	Name string
+	Body []Node
+
+	override *BlockNode   // render edilecek tanım; nil ise kendi gövdesi
+	supers   []*SuperNode // bu tanımın gövdesindeki <{ super }> çağrıları
+}
+
+func (n *BlockNode) Eval(s *state, w io.Writer, data map[string]interface{}) error {
+	b := n
+	if n.override != nil {
+		b = n.override
+	}
+	return evalNodes(s, w, b.Body, data)
+}
+
+// SuperNode: <{ super }>, bir üst template'teki aynı isimli bloğun içeriğini render eder.
+type SuperNode struct {
+	Pos
+	Body []Node
+}
+
+func (n *SuperNode) Eval(s *state, w io.Writer, data map[string]interface{}) error {
+	return evalNodes(s, w, n.Body, data)
+}
+
+// IncludeNode: <{ include "name" [with expr] [only] }>. Başka bir template'i render anında
+// cache üzerinden yükleyip o noktada işler. Name boşsa template ismi NameExpr değişkeninden okunur.
+type IncludeNode struct {
+	Pos
+	Name     string // sabit template ismi
+	NameExpr string // dinamik isim için değişken yolu
+	With     string // opsiyonel: include edilen template'e verilecek değer
+	Only     bool   // true ise üst template'in verisi görünmez, sadece With ve global değerler
+
+	from     string   // include eden template; göreli isimler buna göre çözülür
+	nameExpr expr     // derlenmiş NameExpr
+	with     expr     // derlenmiş With
+	escaping escaping // include eden template'in kaçışı; include edilen template bununla derlenir
+}
+
+// maxIncludeDepth: kendini (dolaylı olarak) include eden template'lere karşı üst sınır
+const maxIncludeDepth = 64
+
+func (n *IncludeNode) Eval(s *state, w io.Writer, data map[string]interface{}) error {
+	name := n.Name
+	if n.NameExpr != "" {
+		v, err := n.nameExpr.eval(s, data)
+		if err != nil {
+			return s.wrap(n.Pos, n.NameExpr, err)
+		}
+		str, isStr := v.(string)
+		if !isStr || str == "" {
+			return s.errorf(n.Pos, n.NameExpr, "include: value does not name a template (got %v)", v)
+		}
+		// veriden gelen isimler ".." ile kök dizinin dışına çıkamaz
+		for _, seg := range strings.Split(filepath.ToSlash(str), "/") {
+			if seg == ".." {
+				return s.errorf(n.Pos, n.NameExpr, "include: template name %q must not contain \"..\"", str)
+			}
+		}
+		name = str
+	}
+	if s.depth >= maxIncludeDepth {
+		return s.errorf(n.Pos, name, "include nested deeper than %d levels", maxIncludeDepth)
+	}
+	file := s.eng.relative(n.from, name)
+	if n.NameExpr != "" && !s.eng.within(file) {
+		return s.errorf(n.Pos, n.NameExpr, "include: template %q is outside the template root", name)
+	}
+	tpl, err := s.eng.getOrCompile(file, n.escaping)
+	if err != nil {
+		return s.wrap(n.Pos, name, err)
+	}
+
+	var ctx map[string]interface{}
+	if n.Only {
+		ctx = shallowCopyMap(s.eng.globals)
+	} else {
+		ctx = shallowCopyMap(data)
+	}
+	if n.With != "" {
+		v, err := n.with.eval(s, data)
+		if err != nil {
+			return s.wrap(n.Pos, n.With, err)
+		}
+		if _, ok := v.(undefined); ok {
+			v = nil
+		}
+		if m, ok := v.(map[string]interface{}); ok {
+			// map verildiyse anahtarları include edilen template'in değişkenleri olur
+			for k, mv := range m {
+				ctx[k] = mv
+			}
+		} else {
+			// diğer değerler yolun son parçasıyla aynı isimle görünür: "with user.profile" -> profile
+			ctx[n.With[strings.LastIndex(n.With, ".")+1:]] = v
+		}
+	}
+
+	s.depth++
+	defer func() { s.depth-- }()
+	return evalNodes(s, w, tpl.Nodes, ctx)
+}
+
+// SetNode: <{ set name = ifade }>. Değeri (varsa filtrelerden geçirip) içinde bulunulan kapsama
+// yazar: if, switch, block ve capture gövdeleri kapsamı paylaşır, for ve with gövdeleri ise kendi
+// kapsamlarını açtığı için içlerindeki atamalar dışarı taşmaz.
+type SetNode struct {
+	Pos
+	Name    string
+	Expr    string
+	Filters []Filter
+
+	value expr
+}
+
+func (n *SetNode) Eval(s *state, w io.Writer, data map[string]interface{}) error {
+	val, err := evalPipeline(s, n.value, n.Filters, data)
+	if err != nil {
+		return s.wrap(n.Pos, n.Expr, err)
+	}
+	data[n.Name] = val
+	return nil
+}
+
+// WithNode: <{ with ifade as name }> ... <{ /with }>. Gövde, name'in ifadenin değerine bağlı
+// olduğu yeni bir kapsamda render edilir.
+type WithNode struct {
+	Pos
+	Name string
+	Expr string
+	Body []Node
+
+	value expr
+}
+
+func (n *WithNode) Eval(s *state, w io.Writer, data map[string]interface{}) error {
+	val, err := n.value.eval(s, data)
+	if err != nil {
+		return s.wrap(n.Pos, n.Expr, err)
+	}
+	if _, ok := val.(undefined); ok {
+		val = nil
+	}
+	scope := shallowCopyMap(data)
+	scope[n.Name] = val
+	return evalNodes(s, w, n.Body, scope)
+}
+
+// CaptureNode: <{ capture name }> ... <{ /capture }>. Gövdeyi yazmak yerine render edip
+// sonucu içinde bulunulan kapsamda name değişkenine koyar. HTML template'lerinde sonuç HTML
+// olur (metinde olduğu gibi, attribute gibi diğer bağlamlarda yeniden kaçışlanarak yazılır);
+// diğer biçimlerde zaten kaçışlanmış olduğu için bir daha kaçışlanmaz.
+type CaptureNode struct {
+	Pos
+	Name string
+	Body []Node
+
+	flat bool // template HTML dışı bir biçimde kaçışlanıyor
+}
+
+func (n *CaptureNode) Eval(s *state, w io.Writer, data map[string]interface{}) error {
+	var b strings.Builder
+	if err := evalNodes(s, &b, n.Body, data); err != nil {
+		return err
+	}
+	if n.flat {
+		data[n.Name] = escaped(b.String())
+	} else {
+		data[n.Name] = HTML(b.String())
 	}
+	return nil
 }
@@ -0,0 +1,91 @@
+package vingo
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestForElseAndLoopControl(t *testing.T) {
+	data := map[string]interface{}{"xs": []int{1, 2, 3, 4, 5}, "none": []int{}}
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{"else not rendered", `<{ for x in xs }><{ x }><{ else }>empty<{ /for }>`, `12345`},
+		{"else empty", `<{ for x in none }><{ x }><{ else }>empty<{ /for }>`, `empty`},
+		{"else missing", `<{ for x in missing }><{ x }><{ else }>empty<{ /for }>`, `empty`},
+		{"break", `<{ for x in xs }><{ if x == 3 }><{ break }><{ /if }><{ x }><{ /for }>`, `12`},
+		{"continue", `<{ for x in xs }><{ if x % 2 == 0 }><{ continue }><{ /if }><{ x }><{ /for }>`, `135`},
+		{"break if", `<{ for x in xs }><{ break if x > 2 }><{ x }><{ /for }>`, `12`},
+		{"continue if", `<{ for x in xs }><{ continue if x == 1 or x == 5 }><{ x }><{ /for }>`, `234`},
+		{"break in elseif", `<{ for x in xs }><{ if x == 1 }>a<{ elseif x == 2 }><{ break }><{ else }>b<{ /if }><{ /for }>`, `a`},
+		{"break in switch", `<{ for x in xs }><{ switch x }><{ case 4 }><{ break }><{ default }><{ x }><{ /switch }><{ /for }>`, `123`},
+		{"continue in switch", `<{ for x in xs }><{ switch x }><{ case 2, 3 }><{ continue }><{ /switch }><{ x }><{ /for }>`, `145`},
+		{"break in with", `<{ for x in xs }><{ with x as y }><{ break if y == 2 }><{ y }><{ /with }><{ /for }>`, `1`},
+		{"break inner only", `<{ for x in 2 }><{ for y in xs }><{ break if y > 2 }><{ x }><{ y }> <{ /for }><{ /for }>`, `01 02 11 12 `},
+		{"continue outer after inner", `<{ for x in 3 }><{ for y in 1 }><{ /for }><{ continue if x == 1 }><{ x }><{ /for }>`, `02`},
+		{"loop in else", `<{ for x in none }><{ else }><{ for y in xs }><{ break if y == 2 }><{ y }><{ /for }><{ /for }>`, `1`},
+		{"output before break", `<{ for x in xs }><{ x }><{ break }><{ /for }>`, `1`},
+	}
+	e := New(Options{})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := renderString(t, e, "t.txt", tt.src, data); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoopControlErrors(t *testing.T) {
+	tests := []struct {
+		src  string
+		want string
+	}{
+		{`<{ break }>`, "outside"},
+		{`<{ if ok }><{ continue }><{ /if }>`, "outside"},
+		{`<{ for x in xs }>a<{ else }><{ break }><{ /for }>`, "outside"},
+		{`<{ for x in xs }>a<{ else }>b<{ else }>c<{ /for }>`, "more than one <{ else }>"},
+		{`<{ for x in xs }>a`, "unclosed for"},
+	}
+	e := New(Options{})
+	for _, tt := range tests {
+		_, err := e.Compile("t.txt", tt.src)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("Compile(%q) error = %v, want it to contain %q", tt.src, err, tt.want)
+		}
+	}
+}
+
+var inheritanceFS = fstest.MapFS{
+	"layout.html":  {Data: []byte(`<title><{ block title }>Site<{ /block }></title><{ block body }><{ /block }>`)},
+	"section.html": {Data: []byte(`<{ extends "layout.html" }><{ block title }>Docs - <{ super }><{ /block }>`)},
+	"page.html":    {Data: []byte(`<{ extends "section.html" }><{ block title }>Page - <{ super }><{ /block }><{ block body }>hi<{ /block }>`)},
+	"orphan.html":  {Data: []byte(`<{ extends "layout.html" }><{ block footer }><{ super }><{ /block }>`)},
+	"top.html":     {Data: []byte(`<{ block title }><{ super }><{ /block }>`)},
+}
+
+func TestSuper(t *testing.T) {
+	e := New(Options{FS: inheritanceFS})
+	tests := []struct{ file, want string }{
+		{"layout.html", `<title>Site</title>`},
+		{"section.html", `<title>Docs - Site</title>`},
+		{"page.html", `<title>Page - Docs - Site</title>hi`},
+	}
+	for _, tt := range tests {
+		got, err := e.Render(tt.file, nil)
+		if err != nil || got != tt.want {
+			t.Errorf("%s = %q, %v; want %q", tt.file, got, err, tt.want)
+		}
+	}
+	for _, file := range []string{"orphan.html", "top.html"} {
+		_, err := e.Render(file, nil)
+		var pe *ParseError
+		if !errors.As(err, &pe) || !strings.Contains(err.Error(), "super used in block") || !strings.Contains(err.Error(), file) {
+			t.Errorf("%s: error = %v, want a ParseError about super", file, err)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package vingo
+
+import (
+	"fmt"
+	"html/template"
+)
+
+// -------------------- Trusted values --------------------
+//
+// Bu türlerdeki değerler güvenilir kabul edilir ve ait oldukları bağlamda kaçışlanmadan yazılır.
+// Güvenlik etiketle değil değerle taşınır: CMS'ten gelen hazır HTML'i vingo.HTML olarak vermek
+// yeterlidir. Başka bir bağlamda kullanılan güvenilir değer, o bağlamın kurallarıyla kaçışlanır.
+
+// HTML: güvenilir bir HTML parçası; HTML metninde olduğu gibi yazılır
+type HTML string
+
+// JS: güvenilir bir JavaScript ifadesi; <script> içinde ve on* attribute'larında olduğu gibi yazılır
+type JS string
+
+// URL: güvenilir bir URL; URL attribute'larında scheme kontrolü yapılmadan yazılır
+type URL string
+
+// CSS: güvenilir CSS; <style> içinde ve style attribute'larında olduğu gibi yazılır
+type CSS string
+
+// escaped: template'in çıktı biçimi için zaten kaçışlanmış metin; hiçbir kaçışa uğramadan yazılır.
+// raw/noescape filtreleri ve HTML dışı template'lerdeki capture bunu üretir.
+type escaped string
+
+// trusted: html/template'in güvenilir türlerini vingo karşılıklarına çevirir, böylece
+// html/template için hazırlanmış değerler de kaçışlanmadan yazılır
+func trusted(v interface{}) interface{} {
+	switch t := v.(type) {
+	case template.HTML:
+		return HTML(t)
+	case template.JS:
+		return JS(t)
+	case template.URL:
+		return URL(t)
+	case template.CSS:
+		return CSS(t)
+	}
+	return v
+}
+
+// trustFilter: değeri T türünde güvenilir bir değer olarak işaretler: <{ page.Body | safe }>
+func trustFilter[T HTML | JS | URL | CSS | escaped](in interface{}, args ...interface{}) (interface{}, error) {
+	if len(args) != 0 {
+		return nil, fmt.Errorf("takes no arguments")
+	}
+	return T(toString(in)), nil
+}
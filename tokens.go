@@ -3,6 +3,7 @@ package vingo
 import (
 	"regexp"
 	"strings"
+	"unicode"
 )
 
 type TokenType int
@@ -20,89 +21,274 @@ const (
 	TCase
 	TDefault
 	TEndSwitch
+	TExtends
+	TBlock
+	TEndBlock
+	TSuper
+	TInclude
+	TBreak
+	TContinue
+	TSet
+	TWith
+	TEndWith
+	TCapture
+	TEndCapture
+	TRaw
+	TEndRaw
+	TComment // sadece kapanmamış yorumlar için; kapanan yorumlar token üretmez
 )
 
 type Token struct {
-	Type    TokenType
-	Value   string // for Var: expression or name; for If/For/Switch/Case: expression / raw
-	Default string // for Var default literal (if provided)
-	Raw     string // raw tag text
+	Type TokenType
+	// for Var: expression and filter chain; for If/For/Switch/Case: expression / raw;
+	// for Set/With: "name:expr"; for Break/Continue: optional condition
+	Value string
+	Raw   string // raw tag text
+	Pos          // tag için "<{" işaretinin, text için metnin başladığı yer
 }
 
 var (
-	varPattern       = regexp.MustCompile(`^\s*(\w+(?:\.\w+)*)(?:\s*\|\s*"(.*?)")?\s*$`)
-	ifPattern        = regexp.MustCompile(`^if\s+(.+)$`)
-	elseifPattern    = regexp.MustCompile(`^elseif\s+(.+)$`)
-	elsePattern      = regexp.MustCompile(`^else$`)
-	endifPattern     = regexp.MustCompile(`^/if$`)
-	forPattern       = regexp.MustCompile(`^for\s+(.+)\s+in\s+(.+)$`)
-	endforPattern    = regexp.MustCompile(`^/for$`)
-	switchPattern    = regexp.MustCompile(`^switch\s+(.+)$`)
-	casePattern      = regexp.MustCompile(`^case\s+(.+)$`)
-	defaultPattern   = regexp.MustCompile(`^default$`)
-	endswitchPattern = regexp.MustCompile(`^/switch$`)
+	ifPattern         = regexp.MustCompile(`^if\s+(.+)$`)
+	elseifPattern     = regexp.MustCompile(`^else\s*if\s+(.+)$`)
+	elsePattern       = regexp.MustCompile(`^else$`)
+	endifPattern      = regexp.MustCompile(`^/if$`)
+	forPattern        = regexp.MustCompile(`^for\s+(.+)\s+in\s+(.+)$`)
+	endforPattern     = regexp.MustCompile(`^/for$`)
+	switchPattern     = regexp.MustCompile(`^switch\s+(.+)$`)
+	casePattern       = regexp.MustCompile(`^case\s+(.+)$`)
+	defaultPattern    = regexp.MustCompile(`^default$`)
+	endswitchPattern  = regexp.MustCompile(`^/switch$`)
+	extendsPattern    = regexp.MustCompile(`^extends\s+(.+)$`)
+	blockPattern      = regexp.MustCompile(`^block\s+(\w+)$`)
+	endblockPattern   = regexp.MustCompile(`^/block(?:\s+\w+)?$`)
+	superPattern      = regexp.MustCompile(`^super(?:\(\))?$`)
+	includePattern    = regexp.MustCompile(`^include\s+(.+)$`)
+	breakPattern      = regexp.MustCompile(`^break(?:\s+if\s+(.+))?$`)
+	continuePattern   = regexp.MustCompile(`^continue(?:\s+if\s+(.+))?$`)
+	setPattern        = regexp.MustCompile(`^set\s+(\w+)\s*=\s*(.+)$`)
+	withPattern       = regexp.MustCompile(`^with\s+(.+)\s+as\s+(\w+)$`)
+	endwithPattern    = regexp.MustCompile(`^/with$`)
+	capturePattern    = regexp.MustCompile(`^capture\s+(\w+)$`)
+	endcapturePattern = regexp.MustCompile(`^/capture$`)
+	rawPattern        = regexp.MustCompile(`^raw$`)
+	endrawPattern     = regexp.MustCompile(`^/raw$`)
+
+	// endrawTag: bir raw bloğunu bitiren etiket, trim işaretleriyle birlikte
+	endrawTag = regexp.MustCompile(`<\{(-?)\s*/raw\s*(-?)\}>`)
 )
 
-func tokenize(input string) []*Token {
+// tokenize: file isimli template kaynağını token'lara ayırır; file sadece konumlarda kullanılır.
+// Metindeki boşluklar olduğu gibi korunur. "<{-" etiketten önceki, "-}>" etiketten sonraki
+// boşlukları (satır sonları dahil) siler. trimBlocks true ise çıktı etiketi olmayan etiketlerin
+// ve yorumların hemen arkasındaki tek satır sonu da silinir. <{# ... #}> yorumları atlanır,
+// <{ raw }> ... <{ /raw }> arasındaki metin olduğu gibi tek bir text token'ı olur. Kapanışı
+// ("}>") bir sonraki "<{"den önce gelmeyen "<{" etiket sayılmaz ve metne aynen yazılır; etiket
+// içindeki tırnaklı string'lerde "}>" ve "<{" aranmaz (bkz. tagEnd).
+func tokenize(file, input string, trimBlocks bool) []*Token {
 	var tokens []*Token
+	lines := &lineCounter{file: file, src: input, line: 1}
+
+	// text: input[from:to] aralığını text token'ı olarak ekler; trim ise sondaki boşlukları siler
+	text := func(from, to int, trim bool) {
+		s := input[from:to]
+		if trim {
+			s = strings.TrimRightFunc(s, unicode.IsSpace)
+		}
+		if s != "" {
+			tokens = append(tokens, &Token{Type: TText, Value: s, Pos: lines.at(from)})
+		}
+	}
+	// skip: etiketten sonraki off noktasından itibaren trim işaretinin veya trimBlocks'un sildiği
+	// boşlukları atlar
+	skip := func(off int, trim, block bool) int {
+		if trim {
+			return len(input) - len(strings.TrimLeftFunc(input[off:], unicode.IsSpace))
+		}
+		if block && trimBlocks {
+			for _, nl := range []string{"\r\n", "\n"} {
+				if strings.HasPrefix(input[off:], nl) {
+					return off + len(nl)
+				}
+			}
+		}
+		return off
+	}
+
+	start := 0 // henüz token'a çevrilmemiş metnin başı
+	for i := 0; ; {
+		j := strings.Index(input[i:], "<{")
+		if j < 0 {
+			break
+		}
+		j += i
+
+		// Yorum: <{# ... #}>, içinde etiket ve "}>" olabilir
+		if strings.HasPrefix(input[j:], "<{#") {
+			end := strings.Index(input[j+3:], "#}>")
+			if end < 0 {
+				text(start, j, false)
+				tokens = append(tokens, &Token{Type: TComment, Raw: "#", Pos: lines.at(j)})
+				return tokens
+			}
+			body := input[j+3 : j+3+end]
+			text(start, j, strings.HasPrefix(body, "-"))
+			start = skip(j+3+end+len("#}>"), strings.HasSuffix(body, "-"), true)
+			i = start
+			continue
+		}
 
-	// Her parçayı ayır ve boş olanları atla
-	parts := strings.Split(input, "<{")
-	for _, part := range parts {
-		part = strings.TrimSpace(part)
-		if part == "" {
+		// Kapanışı bir sonraki "<{"den önce gelmeyen "<{" etiket değildir
+		k := tagEnd(input[j+2:])
+		if k < 0 {
+			i = j + 2
 			continue
 		}
+		tag := input[j+2 : j+2+k]
+		after := j + 2 + k + len("}>")
+
+		trimBefore, trimAfter := strings.HasPrefix(tag, "-"), strings.HasSuffix(tag, "-")
+		tag = strings.TrimPrefix(tag, "-")
+		tag = strings.TrimSpace(strings.TrimSuffix(tag, "-"))
+		text(start, j, trimBefore)
+		t := classify(tag)
+		t.Pos = lines.at(j)
+		tokens = append(tokens, t)
+		start = skip(after, trimAfter, t.Type != TVar)
 
-		// Eğer kapanış "}>” yoksa text olarak ekle, baştaki <{ işaretini kaldırıyoruz
-		sub := strings.SplitN(part, "}>", 2)
-		if len(sub) == 2 {
-			tag := strings.TrimSpace(sub[0])
-			rest := sub[1]
-
-			switch {
-			case ifPattern.MatchString(tag):
-				m := ifPattern.FindStringSubmatch(tag)
-				tokens = append(tokens, &Token{Type: TIf, Value: m[1], Raw: tag})
-			case elseifPattern.MatchString(tag):
-				m := elseifPattern.FindStringSubmatch(tag)
-				tokens = append(tokens, &Token{Type: TElseIf, Value: m[1], Raw: tag})
-			case elsePattern.MatchString(tag):
-				tokens = append(tokens, &Token{Type: TElse, Raw: tag})
-			case endifPattern.MatchString(tag):
-				tokens = append(tokens, &Token{Type: TEndIf, Raw: tag})
-			case forPattern.MatchString(tag):
-				m := forPattern.FindStringSubmatch(tag)
-				tokens = append(tokens, &Token{Type: TFor, Value: strings.TrimSpace(m[1]) + ":" + strings.TrimSpace(m[2]), Raw: tag})
-			case endforPattern.MatchString(tag):
-				tokens = append(tokens, &Token{Type: TEndFor, Raw: tag})
-			case switchPattern.MatchString(tag):
-				m := switchPattern.FindStringSubmatch(tag)
-				tokens = append(tokens, &Token{Type: TSwitch, Value: m[1], Raw: tag})
-			case casePattern.MatchString(tag):
-				m := casePattern.FindStringSubmatch(tag)
-				tokens = append(tokens, &Token{Type: TCase, Value: m[1], Raw: tag})
-			case defaultPattern.MatchString(tag):
-				tokens = append(tokens, &Token{Type: TDefault, Raw: tag})
-			case endswitchPattern.MatchString(tag):
-				tokens = append(tokens, &Token{Type: TEndSwitch, Raw: tag})
-			case varPattern.MatchString(tag):
-				m := varPattern.FindStringSubmatch(tag)
-				tokens = append(tokens, &Token{Type: TVar, Value: m[1], Default: m[2], Raw: tag})
-			default:
-				// bilinmeyen tag text olarak bırak
-				tokens = append(tokens, &Token{Type: TText, Value: rest})
+		// raw: kapanış etiketine kadar olan metin olduğu gibi alınır
+		if t.Type == TRaw {
+			m := endrawTag.FindStringSubmatchIndex(input[start:])
+			if m == nil {
+				return tokens
 			}
+			text(start, start+m[0], m[3] > m[2])
+			end := &Token{Type: TEndRaw, Raw: "/raw", Pos: lines.at(start + m[0])}
+			tokens = append(tokens, end)
+			start = skip(start+m[1], m[5] > m[4], true)
+		}
+		i = start
+	}
+	text(start, len(input), false)
+	return tokens
+}
 
-			// Tag sonrası kalan text varsa ekle
-			if rest != "" {
-				tokens = append(tokens, &Token{Type: TText, Value: rest})
+// tagEnd: "<{"den sonraki s metninde etiketi bitiren "}>"nin yeri. Tırnaklı string'lerin içindeki
+// "}>" ve "<{" etiketin parçasıdır: <{ x | default("}>") }>. Kapanıştan önce tırnak dışında bir "<{"
+// gelirse veya kapanış yoksa -1 döner. Kapanmayan bir tırnak varsa tırnaklar yok sayılır; hatayı
+// ifade parser'ı raporlar.
+func tagEnd(s string) int {
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case quote != 0:
+			if c == '\\' {
+				i++
+			} else if c == quote {
+				quote = 0
 			}
-		} else {
-			// kapanış yoksa direkt text olarak ekle, baştaki <{ işareti eklenmiyor
-			tokens = append(tokens, &Token{Type: TText, Value: part})
+		case c == '"' || c == '\'':
+			quote = c
+		case strings.HasPrefix(s[i:], "}>"):
+			return i
+		case strings.HasPrefix(s[i:], "<{"):
+			return -1
 		}
 	}
+	if quote == 0 {
+		return -1
+	}
+	k := strings.Index(s, "}>")
+	if n := strings.Index(s, "<{"); k < 0 || (n >= 0 && n < k) {
+		return -1
+	}
+	return k
+}
 
-	return tokens
+// classify: "<{" ve "}>" arasındaki (trim işaretleri ve boşlukları atılmış) etiket metninin token'ı
+func classify(tag string) *Token {
+	switch {
+	case ifPattern.MatchString(tag):
+		m := ifPattern.FindStringSubmatch(tag)
+		return &Token{Type: TIf, Value: m[1], Raw: tag}
+	case elseifPattern.MatchString(tag):
+		m := elseifPattern.FindStringSubmatch(tag)
+		return &Token{Type: TElseIf, Value: m[1], Raw: tag}
+	case elsePattern.MatchString(tag):
+		return &Token{Type: TElse, Raw: tag}
+	case endifPattern.MatchString(tag):
+		return &Token{Type: TEndIf, Raw: tag}
+	case forPattern.MatchString(tag):
+		m := forPattern.FindStringSubmatch(tag)
+		return &Token{Type: TFor, Value: strings.TrimSpace(m[1]) + ":" + strings.TrimSpace(m[2]), Raw: tag}
+	case endforPattern.MatchString(tag):
+		return &Token{Type: TEndFor, Raw: tag}
+	case switchPattern.MatchString(tag):
+		m := switchPattern.FindStringSubmatch(tag)
+		return &Token{Type: TSwitch, Value: m[1], Raw: tag}
+	case casePattern.MatchString(tag):
+		m := casePattern.FindStringSubmatch(tag)
+		return &Token{Type: TCase, Value: m[1], Raw: tag}
+	case defaultPattern.MatchString(tag):
+		return &Token{Type: TDefault, Raw: tag}
+	case endswitchPattern.MatchString(tag):
+		return &Token{Type: TEndSwitch, Raw: tag}
+	case extendsPattern.MatchString(tag):
+		m := extendsPattern.FindStringSubmatch(tag)
+		return &Token{Type: TExtends, Value: strings.TrimSpace(m[1]), Raw: tag}
+	case blockPattern.MatchString(tag):
+		m := blockPattern.FindStringSubmatch(tag)
+		return &Token{Type: TBlock, Value: m[1], Raw: tag}
+	case endblockPattern.MatchString(tag):
+		return &Token{Type: TEndBlock, Raw: tag}
+	case superPattern.MatchString(tag):
+		return &Token{Type: TSuper, Raw: tag}
+	case includePattern.MatchString(tag):
+		m := includePattern.FindStringSubmatch(tag)
+		return &Token{Type: TInclude, Value: strings.TrimSpace(m[1]), Raw: tag}
+	case breakPattern.MatchString(tag):
+		m := breakPattern.FindStringSubmatch(tag)
+		return &Token{Type: TBreak, Value: m[1], Raw: tag}
+	case continuePattern.MatchString(tag):
+		m := continuePattern.FindStringSubmatch(tag)
+		return &Token{Type: TContinue, Value: m[1], Raw: tag}
+	case setPattern.MatchString(tag):
+		m := setPattern.FindStringSubmatch(tag)
+		return &Token{Type: TSet, Value: m[1] + ":" + strings.TrimSpace(m[2]), Raw: tag}
+	case withPattern.MatchString(tag):
+		m := withPattern.FindStringSubmatch(tag)
+		return &Token{Type: TWith, Value: m[2] + ":" + strings.TrimSpace(m[1]), Raw: tag}
+	case endwithPattern.MatchString(tag):
+		return &Token{Type: TEndWith, Raw: tag}
+	case capturePattern.MatchString(tag):
+		m := capturePattern.FindStringSubmatch(tag)
+		return &Token{Type: TCapture, Value: m[1], Raw: tag}
+	case endcapturePattern.MatchString(tag):
+		return &Token{Type: TEndCapture, Raw: tag}
+	case rawPattern.MatchString(tag):
+		return &Token{Type: TRaw, Raw: tag}
+	case endrawPattern.MatchString(tag):
+		return &Token{Type: TEndRaw, Raw: tag}
+	}
+	// anahtar kelimeyle başlamayan her tag bir çıktı ifadesidir; geçersizse compile hata verir
+	return &Token{Type: TVar, Value: tag, Raw: tag}
+}
+
+// lineCounter: artan byte offset'lerini satır/sütuna çevirir. Her çağrı bir öncekinden
+// kalınan yerden devam ettiği için bütün template tek geçişte taranır.
+type lineCounter struct {
+	file      string
+	src       string
+	off       int // taranan son offset
+	line      int
+	lineStart int // geçerli satırın başladığı offset
+}
+
+func (c *lineCounter) at(off int) Pos {
+	for ; c.off < off && c.off < len(c.src); c.off++ {
+		if c.src[c.off] == '\n' {
+			c.line++
+			c.lineStart = c.off + 1
+		}
+	}
+	return Pos{File: c.file, Line: c.line, Col: off - c.lineStart + 1}
 }
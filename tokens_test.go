@@ -0,0 +1,124 @@
+package vingo
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWhitespace(t *testing.T) {
+	data := map[string]interface{}{"items": []string{"a", "b"}, "ok": true, "n": 5}
+	tests := []struct {
+		name       string
+		src        string
+		trimBlocks bool
+		want       string
+	}{
+		{"kept", "<ul>\n  <{ for i in items }>\n  <li><{ i }></li>\n  <{ /for }>\n</ul>", false, "<ul>\n  \n  <li>a</li>\n  \n  <li>b</li>\n  \n</ul>"},
+		{"trim both", "<{- for i in items -}>\n    <{ i }>,\n<{- /for }>", false, "a,b,"},
+		{"trim before", "a  \n\t<{- \"b\" }>  c", false, "ab  c"},
+		{"trim after", "a  <{ \"b\" -}>  \n c", false, "a  bc"},
+		{"trim comment", "a\n<{#- note -#}>\nb", false, "ab"},
+		{"negative number", "<{ -1 }>", false, "-1"},
+		{"trim negative", "x <{-1}>", false, "x1"},
+		{"trim blocks", "<{ if ok }>\nyes\n<{ /if }>\n<{ n }>\nend", true, "yes\n5\nend"},
+		{"trim blocks crlf", "<{ if ok }>\r\nyes\r\n<{ /if }>\r\n", true, "yes\r\n"},
+		{"trim blocks one newline", "<{ if ok }>\n\nyes<{ /if }>", true, "\nyes"},
+		{"trim blocks comment", "<{# c #}>\nx", true, "x"},
+		{"switch leading whitespace", "<{ switch n }>\n  <{ case 5 }>five<{ default }>other<{ /switch }>", false, "five"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := New(Options{TrimBlocks: tt.trimBlocks})
+			if got := renderString(t, e, "t.txt", tt.src, data); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTagDelimitersInStrings(t *testing.T) {
+	data := map[string]interface{}{"s": "<{"}
+	tests := []struct {
+		src  string
+		want string
+	}{
+		{`<{ x | default("}>") }>`, `}>`},
+		{`<{ x | default('a}>b') }>`, `a}>b`},
+		{`<{ x | default("a\"}>") }>`, `a"}>`},
+		{`<{ if s == "<{" }>y<{ else }>n<{ /if }>`, `y`},
+		{`a <{ b <{ "c" }>`, `a <{ b c`},
+		{`a <{ b`, `a <{ b`},
+	}
+	e := New(Options{})
+	for _, tt := range tests {
+		if got := renderString(t, e, "t.txt", tt.src, data); got != tt.want {
+			t.Errorf("%s = %q, want %q", tt.src, got, tt.want)
+		}
+	}
+}
+
+func TestWhitespaceErrors(t *testing.T) {
+	e := New(Options{})
+	for _, src := range []string{
+		`<{ switch n }>x<{ case 1 }>a<{ /switch }>`,
+		`<{ x | default("a) }>`,
+	} {
+		if _, err := e.Compile("t.txt", src); err == nil {
+			t.Errorf("Compile(%q) succeeded, want an error", src)
+		}
+	}
+}
+
+func TestCommentsAndRaw(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{"comment", `a<{# note #}>b`, `ab`},
+		{"multiline comment", "a<{# one\n two #}>b", `ab`},
+		{"comment with tags", `a<{# <{ if x }> }> <{ /if }> #}>b`, `ab`},
+		{"comment keeps line", "a\n<{# c #}>\nb", "a\n\nb"},
+		{"raw", `<{ raw }><{ name }> <{ if x }><{ /raw }>`, `<{ name }> <{ if x }>`},
+		{"raw spacing", `<{raw}>a<{/raw}>`, `a`},
+		{"raw trim", "x <{- raw -}>\n  <{ a }>\n<{- /raw -}> y", `x<{ a }>y`},
+		{"raw empty", `a<{ raw }><{ /raw }>b`, `ab`},
+		{"raw comment", `<{ raw }><{# c #}><{ /raw }>`, `<{# c #}>`},
+		{"raw then tags", `<{ raw }><{ x }><{ /raw }><{ x }>`, `<{ x }>1`},
+	}
+	e := New(Options{})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := renderString(t, e, "t.txt", tt.src, map[string]interface{}{"x": 1}); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCommentsAndRawErrors(t *testing.T) {
+	tests := []struct {
+		src  string
+		want string
+	}{
+		{`a<{# note`, "unclosed comment"},
+		{`<{ raw }>a`, "unclosed raw"},
+		{`a<{ /raw }>`, "/raw"},
+	}
+	e := New(Options{})
+	for _, tt := range tests {
+		_, err := e.Compile("t.txt", tt.src)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("Compile(%q) error = %v, want it to contain %q", tt.src, err, tt.want)
+		}
+	}
+}
+
+func TestRawContext(t *testing.T) {
+	// raw içerik de HTML metnidir; bağlam analizine dahil edilir
+	e := New(Options{})
+	got := renderString(t, e, "t.html", `<{ raw }><a title="<{ /raw }><{ x }>">`, map[string]interface{}{"x": `"`})
+	if want := `<a title="&#34;">`; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -1,97 +1,87 @@
 package vingo
 
 import (
-	"os"
-	"path/filepath"
-	"strings"
-	"sync"
+	"fmt"
+	"io"
 	"time"
 )
 
-// AutoEscape: template motoru değişken çıktılarında varsayılan olarak HTML kaçışını (escaping) aktifleştirir.
-// Bunu false yaparak global olarak otomatik kaçışı kapatabilirsiniz (önerilmez).
-var AutoEscape = true
-
 type Template struct {
-	Filepath string
+	Name     string // engine içindeki isim (dosya yolu veya Compile'a verilen isim)
+	Filepath string // dosyadan yüklendiyse okunan yol, aksi halde boş
 	Nodes    []Node
 	ModTime  time.Time
-}
-
-var (
-	// cache: filepath -> compiled template
-	tplCache   = map[string]*Template{}
-	cacheMutex sync.RWMutex
-)
 
-// Render: template dosyasını oku, compile et (gerekirse cache'den), ve işle
-func Render(file string, data map[string]interface{}) (string, error) {
-	abs, err := filepath.Abs(file)
-	if err != nil {
-		abs = file
-	}
+	deps map[string]time.Time // extends ile okunan üst template'ler ve değişiklik zamanları
+	file string               // Name'in resolve edilmiş hali; node konumlarındaki dosya ismi
+	eng  *Engine
+}
 
-	tpl, err := getOrCompile(abs)
-	if err != nil {
-		return "", err
-	}
+// state: tek bir render çağrısı boyunca node'ların paylaştığı bilgiler
+type state struct {
+	eng   *Engine
+	name  string      // render edilen template (hata mesajları için)
+	file  string      // render edilen template'in resolve edilmiş yolu
+	depth int         // iç içe include sayısı
+	dot   interface{} // switch case'leri değerlendirilirken "." ile erişilen switch değeri
+}
 
-	// Evaluate
-	out := &strings.Builder{}
-	for _, n := range tpl.Nodes {
-		out.WriteString(n.Eval(data))
-	}
-	return out.String(), nil
+// errorf: verilen node konumu ve ifade için bir *ExecError üretir
+func (s *state) errorf(pos Pos, expr string, format string, args ...interface{}) error {
+	return &ExecError{Template: s.name, Pos: pos, Expr: expr, Err: fmt.Errorf(format, args...), file: s.file}
 }
 
-// getOrCompile: cache kontrolü + compile
-func getOrCompile(path string) (*Template, error) {
-	stat, err := os.Stat(path)
-	if err != nil {
-		return nil, err
-	}
-	mod := stat.ModTime()
+// wrap: err'i verilen node konumu ve ifadeyle bir *ExecError içine koyar
+func (s *state) wrap(pos Pos, expr string, err error) error {
+	return &ExecError{Template: s.name, Pos: pos, Expr: expr, Err: err, file: s.file}
+}
 
-	cacheMutex.RLock()
-	tpl, exists := tplCache[path]
-	cacheMutex.RUnlock()
+// Render: template dosyasını varsayılan engine ile oku, compile et (gerekirse cache'den), ve işle.
+// Ayrı cache veya kaçış ayarı gerekiyorsa New ile kendi Engine'inizi oluşturun.
+func Render(file string, data map[string]interface{}) (string, error) {
+	return defaultEngine.Render(file, data)
+}
 
-	if exists && tpl.ModTime.Equal(mod) {
-		return tpl, nil
-	}
+// RenderTo: Render gibi, ancak çıktıyı ara string oluşturmadan doğrudan w'ya yazar
+// (örneğin http.ResponseWriter). Büyük çıktılarda bellek kullanımı sabit kalır.
+func RenderTo(w io.Writer, file string, data map[string]interface{}) error {
+	return defaultEngine.RenderTo(w, file, data)
+}
 
-	// compile
-	b, err := os.ReadFile(path)
-	if err != nil {
-		return nil, err
-	}
-	content := string(b)
+// Compile: src içeriğini varsayılan engine ile derler. name hata mesajlarında ve
+// göreli template isimlerinin çözümünde kullanılır; sonuç cache'e konmaz.
+func Compile(name, src string) (*Template, error) {
+	return defaultEngine.Compile(name, src)
+}
 
-	tokens := tokenize(content)
-	nodes, err := compileTokens(tokens)
-	if err != nil {
-		return nil, err
-	}
+// RegisterFilter: varsayılan engine'e bir filtre ekler; bkz. Engine.RegisterFilter.
+func RegisterFilter(name string, fn FilterFunc) {
+	defaultEngine.RegisterFilter(name, fn)
+}
 
-	newTpl := &Template{
-		Filepath: path,
-		Nodes:    nodes,
-		ModTime:  mod,
-	}
+// RegisterEscaper: varsayılan engine'de bir uzantının kaçışını ayarlar; bkz. Engine.RegisterEscaper.
+func RegisterEscaper(ext string, esc Escaper) {
+	defaultEngine.RegisterEscaper(ext, esc)
+}
 
-	cacheMutex.Lock()
-	tplCache[path] = newTpl
-	cacheMutex.Unlock()
+// Funcs: varsayılan engine'e fonksiyonlar ekler; bkz. Engine.Funcs.
+func Funcs(funcs map[string]interface{}) *Engine {
+	return defaultEngine.Funcs(funcs)
+}
 
-	return newTpl, nil
+// Execute: derlenmiş template'i data ile işler ve çıktıyı w'ya yazar.
+func (t *Template) Execute(w io.Writer, data map[string]interface{}) error {
+	s := &state{eng: t.eng, name: t.Name, file: t.file}
+	return evalNodes(s, w, t.Nodes, t.eng.scope(data))
 }
 
-func evalNodes(nodes []Node, data map[string]interface{}) string {
-	out := &strings.Builder{}
+func evalNodes(s *state, w io.Writer, nodes []Node, data map[string]interface{}) error {
 	for _, n := range nodes {
-		out.WriteString(n.Eval(data))
+		if err := n.Eval(s, w, data); err != nil {
+			return err
+		}
 	}
-	return out.String()
+	return nil
 }
 
 func shallowCopyMap(m map[string]interface{}) map[string]interface{} {